@@ -0,0 +1,60 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RunBatch runs a clone of the template for each input state, using up to concurrency
+// workers, and returns the outcomes in input order. The template itself is not run.
+//
+// The clones are made with Machine.Clone, so they share the template's strategy unless it is
+// a CloneableStrategy; a shared strategy must be safe for concurrent use. A template with a
+// History must use a CloneableHistory, since the clones cannot share one; otherwise no clone
+// runs and every outcome reports the error.
+func RunBatch[Services, State any](template *Machine[Services, State], inputs []State, concurrency int) []RunOutcome[Services, State] {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	outcomes := make([]RunOutcome[Services, State], len(inputs))
+	if history := template.Config.History; history != nil {
+		if _, ok := history.(CloneableHistory[Services, State]); !ok {
+			err := fmt.Errorf("machine %s: RunBatch needs a CloneableHistory, got %T", template.Name, history)
+			for i := range outcomes {
+				outcomes[i].Err = err
+			}
+			return outcomes
+		}
+	}
+
+	// The clones are made here rather than by the workers, so that cloning the template's
+	// strategy does not race with itself.
+	machines := make([]*Machine[Services, State], len(inputs))
+	for i := range inputs {
+		machines[i] = template.Clone()
+		machines[i].Context.State = inputs[i]
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				outcomes[i] = machines[i].RunWithOutcome(context.Background())
+			}
+		}()
+	}
+
+	for i := range inputs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return outcomes
+}
@@ -0,0 +1,114 @@
+package tango_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type cloneableHistory struct {
+	countingHistory
+}
+
+func (h *cloneableHistory) Empty() tango.StepHistory[Services, State] {
+	return &cloneableHistory{}
+}
+
+type runBatchTestCase struct {
+	name          string
+	inputs        int
+	concurrency   int
+	history       tango.StepHistory[Services, State]
+	strategy      tango.ExecutionStrategy[Services, State]
+	expectedError string
+}
+
+func TestRunBatch(t *testing.T) {
+	tests := []runBatchTestCase{
+		{
+			name:        "TenInputs",
+			inputs:      10,
+			concurrency: 3,
+		},
+		{
+			name:        "CloneableHistory",
+			inputs:      10,
+			concurrency: 4,
+			history:     &cloneableHistory{},
+		},
+		{
+			name:        "ShuffleStrategy",
+			inputs:      10,
+			concurrency: 4,
+			strategy:    &tango.ShuffleStrategy[Services, State]{Rand: rand.New(rand.NewSource(1))},
+		},
+		{
+			name:          "SharedHistory",
+			inputs:        3,
+			concurrency:   2,
+			history:       &countingHistory{},
+			expectedError: "RunBatch needs a CloneableHistory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Double",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter *= 2
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				tango.BarrierStep[Services, State]("Doubled"),
+				{
+					Name: "Report",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(ctx.State.Counter), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log:     false,
+				History: tt.history,
+			}, &tango.SequentialStrategy[Services, State]{})
+			if tt.strategy != nil {
+				template.Strategy = tt.strategy
+			}
+
+			inputs := make([]State, tt.inputs)
+			for i := range inputs {
+				inputs[i] = State{Counter: i}
+			}
+
+			outcomes := tango.RunBatch(template, inputs, tt.concurrency)
+
+			if len(outcomes) != tt.inputs {
+				t.Fatalf("expected %v outcomes, got %v", tt.inputs, len(outcomes))
+			}
+			for i, outcome := range outcomes {
+				if tt.expectedError != "" {
+					if outcome.Err == nil || !strings.Contains(outcome.Err.Error(), tt.expectedError) {
+						t.Errorf("input %v: expected error %q, got %v", i, tt.expectedError, outcome.Err)
+					}
+					continue
+				}
+				if outcome.Err != nil {
+					t.Errorf("input %v: unexpected error: %v", i, outcome.Err)
+					continue
+				}
+				if outcome.Response == nil || outcome.Response.Result != i*2 {
+					t.Errorf("input %v: expected result %v, got %v", i, i*2, outcome.Response)
+				}
+			}
+			if len(template.ExecutedSteps) != 0 || template.Context.State.Counter != 0 {
+				t.Errorf("expected the template to be left untouched")
+			}
+			if tt.history != nil && tt.history.Len() != 0 {
+				t.Errorf("expected the template's history to be left untouched, got %d steps", tt.history.Len())
+			}
+		})
+	}
+}
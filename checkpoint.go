@@ -0,0 +1,77 @@
+package tango
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Checkpoint is an in-memory copy of a machine's progress taken by Machine.Snapshot, which
+// Machine.Restore rolls the machine back to. Unlike a Suspension it is not meant to be
+// persisted.
+type Checkpoint[Services, State any] struct {
+	// Index is the index in the machine's steps of the step after the last executed one, or 0
+	// if no step was executed.
+	Index          int
+	ExecutedSteps  []Step[Services, State]
+	State          State
+	PreviousResult *Response[Services, State]
+	responses      []*Response[Services, State]
+}
+
+// Snapshot returns a checkpoint of the machine's executed steps, state and previous result.
+// The executed steps are read from the machine's step history, so a custom
+// MachineConfig.History is captured as well. The state is copied with
+// MachineConfig.CloneState when set.
+func (m *Machine[Services, State]) Snapshot() *Checkpoint[Services, State] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	checkpoint := &Checkpoint[Services, State]{
+		State:          m.cloneState(m.Context.State),
+		PreviousResult: m.Context.PreviousResult,
+	}
+	m.history().Reverse(func(step Step[Services, State], response *Response[Services, State]) bool {
+		checkpoint.ExecutedSteps = append(checkpoint.ExecutedSteps, step)
+		checkpoint.responses = append(checkpoint.responses, response)
+		return true
+	})
+	slices.Reverse(checkpoint.ExecutedSteps)
+	slices.Reverse(checkpoint.responses)
+	if n := len(checkpoint.ExecutedSteps); n > 0 {
+		checkpoint.Index = m.stepIndex(checkpoint.ExecutedSteps[n-1].Name) + 1
+	}
+	return checkpoint
+}
+
+// Restore rolls the machine back to the checkpoint: its executed steps, state and previous
+// result become the ones captured by Snapshot. The executed steps replace the ones in the
+// machine's step history, which fails, leaving the machine unchanged, if MachineConfig.History
+// is not a ClearableHistory. The checkpoint is left unchanged, so it can be restored again. To
+// continue from the checkpoint, run the machine with RunFrom on the step at the checkpoint's
+// Index.
+func (m *Machine[Services, State]) Restore(checkpoint *Checkpoint[Services, State]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history, ok := m.history().(ClearableHistory[Services, State])
+	if !ok {
+		return fmt.Errorf("machine %s: Restore needs a ClearableHistory, got %T", m.Name, m.history())
+	}
+	history.Clear()
+	for i, step := range checkpoint.ExecutedSteps {
+		var response *Response[Services, State]
+		if i < len(checkpoint.responses) {
+			response = checkpoint.responses[i]
+		}
+		history.Append(step, response)
+	}
+	m.Context.State = m.cloneState(checkpoint.State)
+	m.Context.PreviousResult = checkpoint.PreviousResult
+	return nil
+}
+
+// cloneState copies state with MachineConfig.CloneState, or by value if it is not set.
+func (m *Machine[Services, State]) cloneState(state State) State {
+	if m.Config != nil && m.Config.CloneState != nil {
+		return m.Config.CloneState(state)
+	}
+	return state
+}
@@ -0,0 +1,194 @@
+package tango_test
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type checkpointTestCase struct {
+	name   string
+	mutate func(m *tango.Machine[Services, State])
+}
+
+func TestMachine_SnapshotRestore(t *testing.T) {
+	tests := []checkpointTestCase{
+		{
+			name: "RunAgain",
+			mutate: func(m *tango.Machine[Services, State]) {
+				if _, err := m.Run(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name: "ChangeState",
+			mutate: func(m *tango.Machine[Services, State]) {
+				m.Context.State.Counter = 100
+				m.Context.PreviousResult = nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Reserve",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return ctx.Machine.Next("Reserved"), nil
+					},
+				},
+				{
+					Name: "Charge",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Charged"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkpoint := m.Snapshot()
+			if checkpoint.Index != 2 {
+				t.Errorf("expected checkpoint index 2, got %d", checkpoint.Index)
+			}
+
+			tt.mutate(m)
+			if err := m.Restore(checkpoint); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if m.Context.State.Counter != 1 {
+				t.Errorf("expected counter 1, got %d", m.Context.State.Counter)
+			}
+			if len(m.ExecutedSteps) != 2 {
+				t.Errorf("expected 2 executed steps, got %d", len(m.ExecutedSteps))
+			}
+			if m.Context.PreviousResult == nil || m.Context.PreviousResult.Result != "Charged" {
+				t.Errorf("expected previous result 'Charged', got %v", m.Context.PreviousResult)
+			}
+		})
+	}
+}
+
+type inventory struct {
+	Items map[string]int
+}
+
+func TestMachine_SnapshotRestore_CloneState(t *testing.T) {
+	m := tango.NewMachine("TestMachine", []tango.Step[Services, inventory]{
+		{
+			Name: "Reserve",
+			Execute: func(ctx *tango.MachineContext[Services, inventory]) (*tango.Response[Services, inventory], error) {
+				ctx.State.Items["widget"]--
+				return ctx.Machine.Next(nil), nil
+			},
+		},
+	}, &tango.MachineContext[Services, inventory]{State: inventory{Items: map[string]int{"widget": 5}}}, &tango.MachineConfig[Services, inventory]{
+		CloneState: func(state inventory) inventory {
+			return inventory{Items: maps.Clone(state.Items)}
+		},
+	}, &tango.SequentialStrategy[Services, inventory]{})
+
+	checkpoint := m.Snapshot()
+	if _, err := m.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Context.State.Items["widget"] != 4 {
+		t.Fatalf("expected 4 widgets after the run, got %d", m.Context.State.Items["widget"])
+	}
+
+	if err := m.Restore(checkpoint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Context.State.Items["widget"] != 5 {
+		t.Errorf("expected 5 widgets after restoring, got %d", m.Context.State.Items["widget"])
+	}
+	if len(m.ExecutedSteps) != 0 {
+		t.Errorf("expected no executed steps after restoring, got %d", len(m.ExecutedSteps))
+	}
+}
+
+type checkpointHistoryTestCase struct {
+	name                string
+	history             tango.StepHistory[Services, State]
+	expectedErr         bool
+	expectedCompensated []string
+}
+
+func TestMachine_SnapshotRestore_History(t *testing.T) {
+	tests := []checkpointHistoryTestCase{
+		{
+			name:                "ClearableHistory",
+			history:             &clearableHistory{},
+			expectedCompensated: []string{"Step3", "Step2", "Step1"},
+		},
+		{
+			name:        "NotClearableHistory",
+			history:     &countingHistory{},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compensated []string
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					return ctx.Machine.Done("Compensated"), nil
+				}
+			}
+			next := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Step1", Execute: next, Compensate: compensate("Step1")},
+				{Name: "Step2", Execute: next, Compensate: compensate("Step2")},
+				{Name: "Step3", Execute: next, Compensate: compensate("Step3")},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				History: tt.history,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkpoint := m.Snapshot()
+			if len(checkpoint.ExecutedSteps) != 3 || checkpoint.Index != 3 {
+				t.Fatalf("expected 3 executed steps at index 3, got %d at index %d", len(checkpoint.ExecutedSteps), checkpoint.Index)
+			}
+			tt.history.Append(m.Steps[2], nil)
+
+			err := m.Restore(checkpoint)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				if tt.history.Len() != 4 {
+					t.Errorf("expected the history to be unchanged, got %d steps", tt.history.Len())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(m.ExecutedSteps) != 0 {
+				t.Errorf("expected the default history to stay empty, got %d steps", len(m.ExecutedSteps))
+			}
+
+			if _, err := m.CompensateContext(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package tango
+
+import (
+	"context"
+	"sync"
+)
+
+// Collector gathers step results of type R. It is safe for concurrent use, so steps running
+// under ConcurrentStrategy can all report into the same collector.
+type Collector[R any] struct {
+	mu      sync.Mutex
+	results []R
+}
+
+// Collect adds result to the collector if it is an R, and reports whether it was added.
+// Results of any other type, including nil, are skipped.
+func (c *Collector[R]) Collect(result any) bool {
+	typed, ok := result.(R)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, typed)
+	return true
+}
+
+// Results returns the collected results in the order they were added.
+func (c *Collector[R]) Results() []R {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	results := make([]R, len(c.results))
+	copy(results, c.results)
+	return results
+}
+
+// CollectedOutcome is the outcome of a run together with the step results collected by
+// RunCollect.
+type CollectedOutcome[R, Services, State any] struct {
+	RunOutcome[Services, State]
+	// Collected holds the result of every successful step execution whose result is an R, in
+	// the order the steps completed.
+	Collected []R
+}
+
+// RunCollect runs the machine like RunWithOutcome and collects the results of its steps that
+// are an R. Results of other types are skipped, as are the results of steps that returned
+// ERROR.
+func RunCollect[R, Services, State any](ctx context.Context, m *Machine[Services, State]) CollectedOutcome[R, Services, State] {
+	var collector Collector[R]
+	m.observe = func(_ string, response *Response[Services, State]) {
+		if response != nil && response.Status != ERROR {
+			collector.Collect(response.Result)
+		}
+	}
+	defer func() { m.observe = nil }()
+
+	outcome := m.RunWithOutcome(ctx)
+	return CollectedOutcome[R, Services, State]{RunOutcome: outcome, Collected: collector.Results()}
+}
@@ -0,0 +1,57 @@
+package tango_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type collectTestCase struct {
+	name     string
+	results  []interface{}
+	expected []int
+}
+
+func TestRunCollect(t *testing.T) {
+	tests := []collectTestCase{
+		{
+			name:     "AllResults",
+			results:  []interface{}{1, 2, 3, 4, 5},
+			expected: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:     "SkipsOtherTypes",
+			results:  []interface{}{1, "two", 3, nil, 5},
+			expected: []int{1, 3, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var steps []tango.Step[Services, State]
+			for i, result := range tt.results {
+				result := result
+				steps = append(steps, tango.Step[Services, State]{
+					Name: string(rune('A' + i)),
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(result), nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: len(steps)})
+			outcome := tango.RunCollect[int](context.Background(), m)
+			if outcome.Err != nil {
+				t.Fatalf("unexpected error: %v", outcome.Err)
+			}
+
+			sort.Ints(outcome.Collected)
+			if !reflect.DeepEqual(outcome.Collected, tt.expected) {
+				t.Errorf("expected collected results %v, got %v", tt.expected, outcome.Collected)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package tango
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CompensateStep is the rollback action for the step named Step in a compensation plan.
+type CompensateStep[Services, State any] struct {
+	Step       string
+	Compensate func(ctx *MachineContext[Services, State]) (*Response[Services, State], error)
+}
+
+// SetCompensationPlan declares the machine's rollback separately from its steps. Once a plan
+// is set, compensation runs the plan's action for each executed step instead of the step's own
+// Compensate function; a step without an action in the plan has nothing to compensate with.
+// The steps' BeforeCompensate, AfterCompensate, CompensateIf and CompensateTimeout still
+// apply. Passing a nil plan restores the per-step Compensate functions. It returns an error,
+// leaving the current plan in place, if a step appears in the plan more than once.
+func (m *Machine[Services, State]) SetCompensationPlan(plan []CompensateStep[Services, State]) error {
+	if plan == nil {
+		m.mu.Lock()
+		m.compensationPlan = nil
+		m.mu.Unlock()
+		return nil
+	}
+	actions := make(map[string]func(ctx *MachineContext[Services, State]) (*Response[Services, State], error), len(plan))
+	for _, action := range plan {
+		if _, ok := actions[action.Step]; ok {
+			return fmt.Errorf("compensation plan has more than one action for step %s", action.Step)
+		}
+		actions[action.Step] = action.Compensate
+	}
+	m.mu.Lock()
+	m.compensationPlan = actions
+	m.mu.Unlock()
+	return nil
+}
+
+// compensateFunc returns the function that compensates the step: its action in the
+// compensation plan if one is set, and its own Compensate function otherwise.
+func (m *Machine[Services, State]) compensateFunc(step Step[Services, State]) func(ctx *MachineContext[Services, State]) (*Response[Services, State], error) {
+	m.mu.Lock()
+	plan := m.compensationPlan
+	m.mu.Unlock()
+	if plan == nil {
+		return step.Compensate
+	}
+	return plan[step.Name]
+}
+
+// rollback runs the strategy's compensation, then the follow-up steps queued during it.
+func (m *Machine[Services, State]) rollback() (*Response[Services, State], error) {
+	m.mu.Lock()
+	m.followUps = nil
+	m.mu.Unlock()
+	response, err := m.Strategy.Compensate(m)
+	if followErr := m.runFollowUps(); followErr != nil {
+		return nil, errors.Join(err, followErr)
+	}
+	return response, err
+}
+
+// runFollowUps runs the queued follow-up compensation steps first in, first out, including the
+// ones they queue themselves, until the queue is empty. It stops at the first failure, once
+// the run's context ends, or once more than the configured number of follow-ups would run, so
+// follow-ups that keep queueing each other cannot roll back forever.
+func (m *Machine[Services, State]) runFollowUps() error {
+	limit := m.Config.MaxFollowUps
+	if limit <= 0 {
+		limit = DefaultMaxFollowUps
+	}
+	for ran := 0; ; ran++ {
+		m.mu.Lock()
+		if len(m.followUps) == 0 {
+			m.mu.Unlock()
+			return nil
+		}
+		step := m.followUps[0]
+		m.followUps = m.followUps[1:]
+		m.mu.Unlock()
+
+		if ran == limit {
+			return fmt.Errorf("compensation queued more than %d follow-up steps", limit)
+		}
+		if err := m.runContext().Err(); err != nil {
+			return fmt.Errorf("follow-up step %s not compensated: %w", step.Name, err)
+		}
+		if err := m.runCompensate(step); err != nil {
+			return fmt.Errorf("follow-up step %s: %w", step.Name, err)
+		}
+	}
+}
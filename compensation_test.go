@@ -0,0 +1,165 @@
+package tango_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type compensationPlanTestCase struct {
+	name          string
+	plan          []string
+	expectedCalls []string
+	expectedErr   string
+}
+
+func TestMachine_SetCompensationPlan(t *testing.T) {
+	tests := []compensationPlanTestCase{
+		{
+			name:          "NoPlan",
+			expectedCalls: []string{"step-Ship", "step-Charge", "step-Reserve"},
+		},
+		{
+			name:          "Plan",
+			plan:          []string{"Reserve", "Charge", "Ship"},
+			expectedCalls: []string{"plan-Ship", "plan-Charge", "plan-Reserve"},
+		},
+		{
+			name:        "DuplicateStep",
+			plan:        []string{"Reserve", "Reserve"},
+			expectedErr: "compensation plan has more than one action for step Reserve",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			compensate := func(call string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					calls = append(calls, call)
+					return nil, nil
+				}
+			}
+			execute := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next(nil), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Reserve", Execute: execute, Compensate: compensate("step-Reserve")},
+				{Name: "Charge", Execute: execute, Compensate: compensate("step-Charge")},
+				{Name: "Ship", Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Error(errors.New("carrier unavailable")), nil
+				}, Compensate: compensate("step-Ship")},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			if tt.plan != nil {
+				var plan []tango.CompensateStep[Services, State]
+				for _, step := range tt.plan {
+					plan = append(plan, tango.CompensateStep[Services, State]{Step: step, Compensate: compensate("plan-" + step)})
+				}
+				err := m.SetCompensationPlan(plan)
+				if tt.expectedErr != "" {
+					if err == nil || err.Error() != tt.expectedErr {
+						t.Fatalf("expected error %q, got %v", tt.expectedErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected the run to fail")
+			}
+			if !reflect.DeepEqual(calls, tt.expectedCalls) {
+				t.Errorf("expected compensations %v, got %v", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+type followUpTestCase struct {
+	name          string
+	depth         int
+	maxFollowUps  int
+	expectedCalls []string
+	expectedErr   string
+}
+
+func TestMachine_CompensateFollowUp(t *testing.T) {
+	tests := []followUpTestCase{
+		{
+			name:          "SingleFollowUp",
+			depth:         1,
+			expectedCalls: []string{"Charge", "Reserve", "cleanup-1"},
+			expectedErr:   "carrier unavailable",
+		},
+		{
+			name:          "Cascade",
+			depth:         3,
+			expectedCalls: []string{"Charge", "Reserve", "cleanup-1", "cleanup-2", "cleanup-3"},
+			expectedErr:   "carrier unavailable",
+		},
+		{
+			name:          "Limit",
+			depth:         5,
+			maxFollowUps:  2,
+			expectedCalls: []string{"Charge", "Reserve", "cleanup-1", "cleanup-2"},
+			expectedErr:   "compensation queued more than 2 follow-up steps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var cleanup func(n int) tango.Step[Services, State]
+			cleanup = func(n int) tango.Step[Services, State] {
+				name := fmt.Sprintf("cleanup-%d", n)
+				return tango.Step[Services, State]{
+					Name: name,
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						calls = append(calls, name)
+						if n == tt.depth {
+							return nil, nil
+						}
+						return &tango.Response[Services, State]{FollowUp: []tango.Step[Services, State]{cleanup(n + 1)}}, nil
+					},
+				}
+			}
+			execute := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next(nil), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Reserve", Execute: execute, Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					calls = append(calls, "Reserve")
+					return &tango.Response[Services, State]{FollowUp: []tango.Step[Services, State]{cleanup(1)}}, nil
+				}},
+				{Name: "Charge", Execute: execute, Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					calls = append(calls, "Charge")
+					return nil, nil
+				}},
+				{Name: "Ship", Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Error(errors.New("carrier unavailable")), nil
+				}, Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return nil, nil
+				}},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				MaxFollowUps: tt.maxFollowUps,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, err := m.Run()
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(calls, tt.expectedCalls) {
+				t.Errorf("expected compensations %v, got %v", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
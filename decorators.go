@@ -0,0 +1,98 @@
+package tango
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RetryStrategy decorates a strategy, running the whole Execute again when it fails. The inner
+// strategy has compensated the failed attempt by then, and its history is cleared so that every
+// attempt starts from the previous result the run started with. Runs stopped by Shutdown or a
+// cancelled context are not retried. MachineConfig.OnDeadLetter is only called for the failure
+// of the final attempt.
+type RetryStrategy[Services, State any] struct {
+	Inner  ExecutionStrategy[Services, State]
+	Policy RetryPolicy
+}
+
+// WithRetry wraps inner so that failed executions are retried according to policy.
+func WithRetry[Services, State any](inner ExecutionStrategy[Services, State], policy RetryPolicy) *RetryStrategy[Services, State] {
+	return &RetryStrategy[Services, State]{Inner: inner, Policy: policy}
+}
+
+func (r *RetryStrategy[Services, State]) Execute(m *Machine[Services, State]) (*Response[Services, State], error) {
+	previous := m.previousResult()
+	enclosing := m.holdDeadLetters(true)
+	defer func() {
+		m.holdDeadLetters(enclosing)
+		m.releaseDeadLetters(true)
+	}()
+	response, err := r.Inner.Execute(m)
+	for retry := 1; retry < r.Policy.MaxAttempts && err != nil; retry++ {
+		if errors.Is(err, ErrShutdown) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+		if sleepErr := sleepBackoff(m.runContext(), r.Policy.JitteredDelay(retry)); sleepErr != nil {
+			return nil, fmt.Errorf("machine %s retry interrupted: %w", m.Name, sleepErr)
+		}
+		m.releaseDeadLetters(false)
+		m.resetAttempt(previous)
+		response, err = r.Inner.Execute(m)
+	}
+	return response, err
+}
+
+// Compensate delegates to the inner strategy.
+func (r *RetryStrategy[Services, State]) Compensate(m *Machine[Services, State]) (*Response[Services, State], error) {
+	return r.Inner.Compensate(m)
+}
+
+// Clone returns a RetryStrategy with the same policy around a clone of the inner strategy.
+func (r *RetryStrategy[Services, State]) Clone() ExecutionStrategy[Services, State] {
+	return &RetryStrategy[Services, State]{Inner: cloneStrategy(r.Inner), Policy: r.Policy}
+}
+
+// LoggingStrategy decorates a strategy, logging when Execute and Compensate start and how
+// they end.
+type LoggingStrategy[Services, State any] struct {
+	Inner  ExecutionStrategy[Services, State]
+	Logger *log.Logger
+}
+
+// WithLogging wraps inner so that its executions and compensations are logged to logger.
+func WithLogging[Services, State any](inner ExecutionStrategy[Services, State], logger *log.Logger) *LoggingStrategy[Services, State] {
+	return &LoggingStrategy[Services, State]{Inner: inner, Logger: logger}
+}
+
+func (l *LoggingStrategy[Services, State]) Execute(m *Machine[Services, State]) (*Response[Services, State], error) {
+	return l.log(m, "execute", l.Inner.Execute)
+}
+
+func (l *LoggingStrategy[Services, State]) Compensate(m *Machine[Services, State]) (*Response[Services, State], error) {
+	return l.log(m, "compensate", l.Inner.Compensate)
+}
+
+// Clone returns a LoggingStrategy with the same logger around a clone of the inner strategy.
+func (l *LoggingStrategy[Services, State]) Clone() ExecutionStrategy[Services, State] {
+	return &LoggingStrategy[Services, State]{Inner: cloneStrategy(l.Inner), Logger: l.Logger}
+}
+
+// log runs fn, logging its start and outcome.
+func (l *LoggingStrategy[Services, State]) log(
+	m *Machine[Services, State],
+	action string,
+	fn func(m *Machine[Services, State]) (*Response[Services, State], error),
+) (*Response[Services, State], error) {
+	l.Logger.Printf("[%s] machine %s: %s started", m.Context.RunID, m.Name, action)
+	start := time.Now()
+	response, err := fn(m)
+	if err != nil {
+		l.Logger.Printf("[%s] machine %s: %s failed after %v: %v", m.Context.RunID, m.Name, action, time.Since(start), err)
+	} else {
+		l.Logger.Printf("[%s] machine %s: %s finished after %v", m.Context.RunID, m.Name, action, time.Since(start))
+	}
+	return response, err
+}
@@ -0,0 +1,149 @@
+package tango_test
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type decoratorsTestCase struct {
+	name             string
+	failures         int
+	maxAttempts      int
+	expectedAttempts int
+	expectedLog      []string
+	expectError      bool
+}
+
+func TestStrategyDecorators(t *testing.T) {
+	tests := []decoratorsTestCase{
+		{
+			name:             "RetryThenSucceed",
+			failures:         1,
+			maxAttempts:      3,
+			expectedAttempts: 2,
+			expectedLog:      []string{"execute started", "compensate started", "execute failed", "execute started", "execute finished"},
+		},
+		{
+			name:             "RetriesExhausted",
+			failures:         5,
+			maxAttempts:      2,
+			expectedAttempts: 2,
+			expectedLog:      []string{"execute failed", "execute failed"},
+			expectError:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			attempts := 0
+
+			strategy := tango.WithRetry(
+				tango.WithLogging[Services, State](&tango.SequentialStrategy[Services, State]{}, log.New(&buf, "", 0)),
+				tango.RetryPolicy{MaxAttempts: tt.maxAttempts},
+			)
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Flaky",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						attempts++
+						if attempts <= tt.failures {
+							return ctx.Machine.Error("flaky"), nil
+						}
+						return ctx.Machine.Done("Done"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return nil, nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, strategy)
+
+			_, err := m.Run()
+			if (err != nil) != tt.expectError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, attempts)
+			}
+
+			logged := buf.String()
+			position := 0
+			for _, line := range tt.expectedLog {
+				index := strings.Index(logged[position:], line)
+				if index < 0 {
+					t.Fatalf("expected %q after position %d in log:\n%s", line, position, logged)
+				}
+				position += index + len(line)
+			}
+		})
+	}
+}
+
+type retryHistoryTestCase struct {
+	name                  string
+	maxAttempts           int
+	expectedCompensations []int
+}
+
+func TestRetryStrategy_ResetsHistory(t *testing.T) {
+	tests := []retryHistoryTestCase{
+		{
+			name:                  "CompensatesOnlyTheFailedAttempt",
+			maxAttempts:           3,
+			expectedCompensations: []int{1, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt := 0
+			compensations := make([]int, tt.maxAttempts)
+			var previous []*tango.Response[Services, State]
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Reserve",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						attempt++
+						previous = append(previous, ctx.PreviousResult)
+						return ctx.Machine.Next("reserved"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensations[attempt-1]++
+						return nil, nil
+					},
+				},
+				{
+					Name: "Charge",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("declined"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return nil, nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, tango.WithRetry[Services, State](
+				&tango.SequentialStrategy[Services, State]{},
+				tango.RetryPolicy{MaxAttempts: tt.maxAttempts},
+			))
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected an error")
+			}
+			if !reflect.DeepEqual(compensations, tt.expectedCompensations) {
+				t.Errorf("expected compensations %v per attempt, got %v", tt.expectedCompensations, compensations)
+			}
+			for i, result := range previous {
+				if result != nil {
+					t.Errorf("expected attempt %d to start without a previous result, got %v", i+1, result.Result)
+				}
+			}
+		})
+	}
+}
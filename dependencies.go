@@ -0,0 +1,111 @@
+package tango
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dependencyOrder orders steps so that each one comes after the steps it must run after,
+// keeping the given order otherwise. Steps never move across a barrier, so the barriers split
+// steps into phases. It fails if a step depends on a step that is not among steps, on the
+// barrier closing its phase or a step of a later phase, or if the dependencies form a cycle.
+func dependencyOrder[Services, State any](steps []Step[Services, State]) ([]Step[Services, State], error) {
+	scheduled := make(map[string]int, len(steps))
+	barriers := make(map[string]bool)
+	for _, step := range steps {
+		scheduled[step.Name]++
+		if step.Barrier {
+			barriers[step.Name] = true
+		}
+	}
+	for _, step := range steps {
+		for _, dependency := range step.MustRunAfter {
+			if scheduled[dependency] == 0 {
+				return nil, fmt.Errorf("step %s must run after %s, which is not scheduled", step.Name, dependency)
+			}
+		}
+	}
+
+	ordered := make([]Step[Services, State], 0, len(steps))
+	placed := make(map[string]int, len(steps))
+	var phase []Step[Services, State]
+	for i, step := range steps {
+		if !step.Barrier {
+			phase = append(phase, step)
+		}
+		if step.Barrier || i == len(steps)-1 {
+			if err := checkPhase(phase, scheduled, placed, barriers); err != nil {
+				return nil, err
+			}
+			var err error
+			if ordered, err = orderPhase(ordered, phase, scheduled, placed); err != nil {
+				return nil, err
+			}
+			phase = nil
+		}
+		if step.Barrier {
+			ordered = append(ordered, step)
+			placed[step.Name]++
+		}
+	}
+	return ordered, nil
+}
+
+// checkPhase fails if a step of a phase must run after a step that is not placed before the
+// phase nor part of it, which is a barrier closing the phase or a step of a later phase.
+func checkPhase[Services, State any](phase []Step[Services, State], scheduled, placed map[string]int, barriers map[string]bool) error {
+	inPhase := make(map[string]int, len(phase))
+	for _, step := range phase {
+		inPhase[step.Name]++
+	}
+	for _, step := range phase {
+		for _, dependency := range step.MustRunAfter {
+			if placed[dependency]+inPhase[dependency] >= scheduled[dependency] {
+				continue
+			}
+			if barriers[dependency] {
+				return fmt.Errorf("step %s must run after barrier %s, which comes after it", step.Name, dependency)
+			}
+			return fmt.Errorf("step %s must run after %s, which runs after a barrier that follows it", step.Name, dependency)
+		}
+	}
+	return nil
+}
+
+// orderPhase appends the steps of a phase between barriers to ordered, each after the steps
+// it must run after, and counts them in placed. A dependency is placed once every scheduled
+// step with its name is.
+func orderPhase[Services, State any](ordered, remaining []Step[Services, State], scheduled, placed map[string]int) ([]Step[Services, State], error) {
+	for len(remaining) > 0 {
+		var next []Step[Services, State]
+		progress := false
+		for _, step := range remaining {
+			if !progress && dependenciesPlaced(step, scheduled, placed) {
+				ordered = append(ordered, step)
+				placed[step.Name]++
+				progress = true
+				continue
+			}
+			next = append(next, step)
+		}
+		if !progress {
+			names := make([]string, 0, len(remaining))
+			for _, step := range remaining {
+				names = append(names, step.Name)
+			}
+			return nil, fmt.Errorf("steps [%s] cannot be ordered: MustRunAfter forms a cycle", strings.Join(names, ", "))
+		}
+		remaining = next
+	}
+	return ordered, nil
+}
+
+// dependenciesPlaced reports whether every step the step must run after is placed.
+func dependenciesPlaced[Services, State any](step Step[Services, State], scheduled, placed map[string]int) bool {
+	for _, dependency := range step.MustRunAfter {
+		if placed[dependency] < scheduled[dependency] {
+			return false
+		}
+	}
+	return true
+}
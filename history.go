@@ -0,0 +1,117 @@
+package tango
+
+// StepHistory stores the steps executed by a machine along with their responses.
+type StepHistory[Services, State any] interface {
+	// Append records an executed step and the response it produced.
+	Append(step Step[Services, State], response *Response[Services, State])
+	// Len returns the number of recorded steps.
+	Len() int
+	// Reverse calls fn for each recorded step, most recent first, until fn returns false.
+	Reverse(fn func(step Step[Services, State], response *Response[Services, State]) bool)
+}
+
+// ClearableHistory is a StepHistory that can drop the steps it recorded. The machine clears a
+// history that implements it when a run restarts or is retried, as it does the default
+// history; other histories keep those steps.
+type ClearableHistory[Services, State any] interface {
+	StepHistory[Services, State]
+	// Clear removes every recorded step.
+	Clear()
+}
+
+// CloneableHistory is a StepHistory that can create an empty history of its kind. Machine.Clone
+// gives the clone such a history of its own; other histories are shared with the clone.
+type CloneableHistory[Services, State any] interface {
+	StepHistory[Services, State]
+	// Empty returns a new history of the same kind with no recorded steps.
+	Empty() StepHistory[Services, State]
+}
+
+// history returns the configured step history, or the default one backed by ExecutedSteps.
+func (m *Machine[Services, State]) history() StepHistory[Services, State] {
+	if m.Config.History != nil {
+		return m.Config.History
+	}
+	return executedSteps[Services, State]{m: m}
+}
+
+// executedSteps is the default in-memory StepHistory, kept in Machine.ExecutedSteps.
+type executedSteps[Services, State any] struct {
+	m *Machine[Services, State]
+}
+
+func (h executedSteps[Services, State]) Append(step Step[Services, State], response *Response[Services, State]) {
+	h.m.ExecutedSteps = append(h.m.ExecutedSteps, step)
+	h.m.responses = append(h.m.responses, response)
+}
+
+func (h executedSteps[Services, State]) Clear() {
+	h.m.ExecutedSteps = nil
+	h.m.responses = nil
+}
+
+func (h executedSteps[Services, State]) Len() int {
+	return len(h.m.ExecutedSteps)
+}
+
+func (h executedSteps[Services, State]) Reverse(fn func(step Step[Services, State], response *Response[Services, State]) bool) {
+	for i := len(h.m.ExecutedSteps) - 1; i >= 0; i-- {
+		var response *Response[Services, State]
+		if i < len(h.m.responses) {
+			response = h.m.responses[i]
+		}
+		if !fn(h.m.ExecutedSteps[i], response) {
+			return
+		}
+	}
+}
+
+// attempt is a failed step that a fallback replaced, kept with the response that produced
+// the step's result.
+type attempt[Services, State any] struct {
+	step     Step[Services, State]
+	response *Response[Services, State]
+}
+
+// sinceSavepoint limits a history to the steps recorded after the most recent step that
+// returned SAVEPOINT or RESTART, which are the steps a compensation rolls back. A step a
+// fallback replaced follows the fallback that produced the recorded response.
+type sinceSavepoint[Services, State any] struct {
+	StepHistory[Services, State]
+}
+
+func (h sinceSavepoint[Services, State]) Len() int {
+	n := 0
+	h.Reverse(func(Step[Services, State], *Response[Services, State]) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func (h sinceSavepoint[Services, State]) Reverse(fn func(step Step[Services, State], response *Response[Services, State]) bool) {
+	h.StepHistory.Reverse(func(step Step[Services, State], response *Response[Services, State]) bool {
+		if response != nil && (response.Status == SAVEPOINT || response.Status == RESTART) {
+			return false
+		}
+		if !fn(step, response) {
+			return false
+		}
+		if response == nil {
+			return true
+		}
+		for i := len(response.attempts) - 1; i >= 0; i-- {
+			if !fn(response.attempts[i].step, response.attempts[i].response) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// clearHistory clears the run's step history if it is a ClearableHistory. m.mu must be held.
+func (m *Machine[Services, State]) clearHistory() {
+	if history, ok := m.history().(ClearableHistory[Services, State]); ok {
+		history.Clear()
+	}
+}
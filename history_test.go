@@ -0,0 +1,166 @@
+package tango_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type countingHistory struct {
+	steps   []tango.Step[Services, State]
+	appends int
+}
+
+func (h *countingHistory) Append(step tango.Step[Services, State], response *tango.Response[Services, State]) {
+	h.appends++
+	h.steps = append(h.steps, step)
+}
+
+func (h *countingHistory) Len() int {
+	return len(h.steps)
+}
+
+func (h *countingHistory) Reverse(fn func(step tango.Step[Services, State], response *tango.Response[Services, State]) bool) {
+	for i := len(h.steps) - 1; i >= 0; i-- {
+		if !fn(h.steps[i], nil) {
+			return
+		}
+	}
+}
+
+type historyTestCase struct {
+	name                string
+	expectedAppends     int
+	expectedCompensated []string
+}
+
+func TestMachine_History(t *testing.T) {
+	tests := []historyTestCase{
+		{
+			name:                "CustomHistoryBacksCompensation",
+			expectedAppends:     3,
+			expectedCompensated: []string{"Step3", "Step2", "Step1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &countingHistory{}
+			var compensated []string
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					return ctx.Machine.Done("Compensated"), nil
+				}
+			}
+			next := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Step1", Execute: next, Compensate: compensate("Step1")},
+				{Name: "Step2", Execute: next, Compensate: compensate("Step2")},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("I will be compensated"), nil
+					},
+					Compensate: compensate("Step3"),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log:     false,
+				History: history,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, _ = m.Run()
+
+			if history.appends != tt.expectedAppends {
+				t.Errorf("expected %v appends, got %v", tt.expectedAppends, history.appends)
+			}
+			if len(m.ExecutedSteps) != 0 {
+				t.Errorf("expected executed steps to live in the custom history, got %v", len(m.ExecutedSteps))
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+type clearableHistory struct {
+	countingHistory
+	clears int
+}
+
+func (h *clearableHistory) Clear() {
+	h.clears++
+	h.steps = nil
+}
+
+type clearableHistoryTestCase struct {
+	name                string
+	expectedClears      int
+	expectedCompensated []string
+}
+
+func TestMachine_History_ClearedOnRestart(t *testing.T) {
+	tests := []clearableHistoryTestCase{
+		{
+			name:                "RestartClearsHistory",
+			expectedClears:      1,
+			expectedCompensated: []string{"Step3", "Step2", "Step1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &clearableHistory{}
+			var compensated []string
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					return nil, nil
+				}
+			}
+			next := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+			restarted := false
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Step1", Execute: next, Compensate: compensate("Step1")},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if !restarted {
+							restarted = true
+							return ctx.Machine.Restart("again"), nil
+						}
+						return ctx.Machine.Next("Next"), nil
+					},
+					Compensate: compensate("Step2"),
+				},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("I will be compensated"), nil
+					},
+					Compensate: compensate("Step3"),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				History: history,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected an error")
+			}
+			if history.clears != tt.expectedClears {
+				t.Errorf("expected %d clears, got %d", tt.expectedClears, history.clears)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
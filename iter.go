@@ -0,0 +1,43 @@
+//go:build go1.23
+
+package tango
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// RunIter runs the machine as the returned sequence is ranged over, yielding each executed
+// step's name and response as the step completes. Breaking out of the loop cancels the run,
+// so no further step starts. If the run ends with an error, a final pair with an empty name
+// and an ERROR response carrying the error is yielded.
+func (m *Machine[Services, State]) RunIter() iter.Seq2[string, *Response[Services, State]] {
+	return func(yield func(string, *Response[Services, State]) bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var mu sync.Mutex
+		stopped := false
+		m.observe = func(step string, response *Response[Services, State]) {
+			mu.Lock()
+			defer mu.Unlock()
+			if stopped {
+				return
+			}
+			if !yield(step, response) {
+				stopped = true
+				cancel()
+			}
+		}
+		defer func() { m.observe = nil }()
+
+		_, err := m.RunContext(ctx)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil && !stopped {
+			yield("", m.Error(err))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+//go:build go1.23
+
+package tango_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type runIterTestCase struct {
+	name             string
+	breakAfter       string
+	expectedYielded  []string
+	expectedExecuted []string
+}
+
+func TestMachine_RunIter(t *testing.T) {
+	tests := []runIterTestCase{
+		{
+			name:             "Complete",
+			expectedYielded:  []string{"Step1", "Step2", "Step3"},
+			expectedExecuted: []string{"Step1", "Step2", "Step3"},
+		},
+		{
+			name:             "BreakAfterStep1",
+			breakAfter:       "Step1",
+			expectedYielded:  []string{"Step1"},
+			expectedExecuted: []string{"Step1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed []string
+			step := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executed = append(executed, name)
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Step1"), step("Step2"), step("Step3"),
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			var yielded []string
+			for name, response := range m.RunIter() {
+				if response.Result != name {
+					t.Errorf("expected result %v for %s, got %v", name, name, response.Result)
+				}
+				yielded = append(yielded, name)
+				if name == tt.breakAfter {
+					break
+				}
+			}
+
+			if !reflect.DeepEqual(yielded, tt.expectedYielded) {
+				t.Errorf("expected yielded steps %v, got %v", tt.expectedYielded, yielded)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package tango
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// redacted replaces the value of a redacted field in the log output.
+const redacted = "[REDACTED]"
+
+// NewRedactingLogPlugin returns a plugin that logs a run's lifecycle to logger: when it starts,
+// the status and result of every step, and when it ends. Results are logged as JSON with the
+// value of every field or map key named in fields, matched case-insensitively and at any
+// depth, replaced by "[REDACTED]". A result that cannot be encoded as JSON is logged by its
+// type only, so nothing unredacted is printed.
+func NewRedactingLogPlugin[Services, State any](logger *log.Logger, fields []string) Plugin[Services, State] {
+	return Plugin[Services, State]{
+		Name: "redacting-log",
+		Init: func(ctx *MachineContext[Services, State]) error {
+			logger.Printf("[%s] machine %s: run started", ctx.RunID, ctx.Machine.Name)
+			return nil
+		},
+		OnResult: func(ctx *MachineContext[Services, State], step string, response *Response[Services, State]) {
+			logger.Printf("[%s] step %s: %s %s", ctx.RunID, step, response.Status, redact(response.Result, fields))
+		},
+		Cleanup: func(ctx *MachineContext[Services, State]) error {
+			logger.Printf("[%s] machine %s: run finished", ctx.RunID, ctx.Machine.Name)
+			return nil
+		},
+	}
+}
+
+// redact renders result as JSON with the values of the named fields replaced.
+func redact(result any, fields []string) string {
+	if err, ok := result.(error); ok {
+		result = err.Error()
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprintf("<%T>", result)
+	}
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return fmt.Sprintf("<%T>", result)
+	}
+	encoded, err = json.Marshal(redactValue(decoded, fields))
+	if err != nil {
+		return fmt.Sprintf("<%T>", result)
+	}
+	return string(encoded)
+}
+
+// redactValue replaces the values of the named fields in a decoded JSON value.
+func redactValue(value any, fields []string) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if redactedField(key, fields) {
+				value[key] = redacted
+			} else {
+				value[key] = redactValue(field, fields)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redactValue(item, fields)
+		}
+	}
+	return value
+}
+
+// redactedField reports whether key is one of fields, ignoring case.
+func redactedField(key string, fields []string) bool {
+	for _, field := range fields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package tango_test
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type credentials struct {
+	User     string
+	Password string
+	Session  map[string]string
+}
+
+type redactingLogTestCase struct {
+	name           string
+	result         interface{}
+	expectedOutput []string
+	hidden         []string
+}
+
+func TestNewRedactingLogPlugin(t *testing.T) {
+	tests := []redactingLogTestCase{
+		{
+			name:           "Struct",
+			result:         credentials{User: "ada", Password: "hunter2", Session: map[string]string{"token": "s3cr3t", "region": "eu"}},
+			expectedOutput: []string{`"User":"ada"`, `"Password":"[REDACTED]"`, `"token":"[REDACTED]"`, `"region":"eu"`},
+			hidden:         []string{"hunter2", "s3cr3t"},
+		},
+		{
+			name:           "NestedMap",
+			result:         []map[string]interface{}{{"password": "hunter2", "id": 1}},
+			expectedOutput: []string{`[{"id":1,"password":"[REDACTED]"}]`},
+			hidden:         []string{"hunter2"},
+		},
+		{
+			name:           "NotEncodable",
+			result:         func() string { return "hunter2" },
+			expectedOutput: []string{"<func() string>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			logger := log.New(&output, "", 0)
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Login",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(tt.result), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Plugins:  []tango.Plugin[Services, State]{tango.NewRedactingLogPlugin[Services, State](logger, []string{"password", "token"})},
+				NewRunID: func() string { return "run-1" },
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logged := output.String()
+			for _, expected := range append([]string{"[run-1] machine TestMachine: run started", "[run-1] step Login: DONE", "[run-1] machine TestMachine: run finished"}, tt.expectedOutput...) {
+				if !strings.Contains(logged, expected) {
+					t.Errorf("expected log output to contain %q, got:\n%s", expected, logged)
+				}
+			}
+			for _, secret := range tt.hidden {
+				if strings.Contains(logged, secret) {
+					t.Errorf("expected %q to be redacted, got:\n%s", secret, logged)
+				}
+			}
+		})
+	}
+}
@@ -1,16 +1,159 @@
 package tango
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// DefaultMaxRequeues is the number of times a step may requeue in a row when
+// MachineConfig.MaxRequeues is not set.
+const DefaultMaxRequeues = 10
+
+// DefaultMaxRestarts is the number of times a run may restart when MachineConfig.MaxRestarts
+// is not set.
+const DefaultMaxRestarts = 10
+
+// MissingJump is what a sequential run does when a step returns JUMP with a target that is
+// not one of the machine's steps.
+type MissingJump int
+
+const (
+	// MissingJumpError fails the run with a "jump target not found" error, leaving the
+	// executed steps uncompensated.
+	MissingJumpError MissingJump = iota
+	// MissingJumpIgnore continues with the step after the jumping step, as if it returned NEXT.
+	MissingJumpIgnore
+	// MissingJumpCompensate fails the run like an ERROR response, compensating the executed
+	// steps.
+	MissingJumpCompensate
+)
+
+// DefaultMaxFollowUps is the number of follow-up compensation steps a rollback may run when
+// MachineConfig.MaxFollowUps is not set.
+const DefaultMaxFollowUps = 100
+
+// ErrShutdown is returned by a run that was stopped by Shutdown.
+var ErrShutdown = errors.New("machine shut down")
+
 // ResponseStatus is a type that represents the status of a response.
 type MachineContext[Services, State any] struct {
 	Services       Services
 	PreviousResult *Response[Services, State]
 	State          State
 	Machine        *Machine[Services, State]
+	// Input is the value the running step's MapInput produced from the previous result. It is
+	// only set while a step with MapInput runs.
+	Input interface{}
+	// Iteration is the index, counting from 0, of the current execution of a step with Repeat.
+	// It is 0 for other steps.
+	Iteration int
+	// RunID identifies the current run. It is set when a run starts and included in the
+	// machine's logs.
+	RunID string
+	ctx   context.Context
+	// step is the name of the running step, set while it runs when progress is reported to a
+	// subscriber.
+	step string
+	// slot is the Semaphore slot the running step holds, if the machine has a Semaphore.
+	slot *slot
+}
+
+// Context returns the context of the running step, which is the run's context unless
+// the step narrowed it (for example with WithStepTimeout).
+func (c *MachineContext[Services, State]) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	if c.Machine != nil {
+		return c.Machine.runContext()
+	}
+	return context.Background()
+}
+
+// scope returns a copy of c for a step to run against, and a function that merges the copy's
+// State back into c once the step is done with it. The State is only written back if the copy's
+// changed, so that the copy does not undo the changes other steps sharing c made meanwhile.
+// Both take the machine's lock.
+func (c *MachineContext[Services, State]) scope() (*MachineContext[Services, State], func()) {
+	unlock := c.lock()
+	scoped := *c
+	unlock()
+	before := scoped.State
+	return &scoped, func() {
+		if reflect.DeepEqual(scoped.State, before) {
+			return
+		}
+		unlock := c.lock()
+		c.State = scoped.State
+		unlock()
+	}
+}
+
+// lock takes the lock of the machine c belongs to, if any, and returns the function that
+// releases it.
+func (c *MachineContext[Services, State]) lock() func() {
+	if c.Machine == nil {
+		return func() {}
+	}
+	c.Machine.mu.Lock()
+	return c.Machine.mu.Unlock
+}
+
+// withStep returns a scoped copy of c that reports progress for the named step, so that the
+// name is not shared with other steps running against c.
+func (c *MachineContext[Services, State]) withStep(step string) (*MachineContext[Services, State], func()) {
+	scoped, merge := c.scope()
+	scoped.step = step
+	return scoped, merge
+}
+
+// withSlot returns a scoped copy of c for a step that holds the semaphore slot s.
+func (c *MachineContext[Services, State]) withSlot(s *slot) (*MachineContext[Services, State], func()) {
+	scoped, merge := c.scope()
+	scoped.slot = s
+	return scoped, merge
+}
+
+// withContext returns a scoped copy of c whose Context is ctx, so that a function can run
+// under a narrower context without changing the one c holds for everyone sharing it.
+func (c *MachineContext[Services, State]) withContext(ctx context.Context) (*MachineContext[Services, State], func()) {
+	scoped, merge := c.scope()
+	scoped.ctx = ctx
+	return scoped, merge
+}
+
+// Sleep pauses the step for d, returning the context's error early if the run is cancelled
+// or the step's context ends first.
+func (c *MachineContext[Services, State]) Sleep(d time.Duration) error {
+	return sleepContext(c.Context(), d)
+}
+
+// ReportProgress reports how far the running step has come, as a fraction between 0 and 1,
+// with a message, to MachineConfig.OnProgress and the plugins' OnProgress hooks. Fractions
+// outside that range are clamped. It does nothing if no one subscribes to progress.
+func (c *MachineContext[Services, State]) ReportProgress(fraction float64, message string) {
+	if c.Machine == nil {
+		return
+	}
+	c.Machine.reportProgress(c, min(max(fraction, 0), 1), message)
+}
+
+// AddStep adds a step to the running machine from within a step, and returns the name it was
+// registered under. The step runs later in the current run and stays on the machine. Steps
+// are only added this way under SequentialStrategy: ConcurrentStrategy schedules its steps
+// before any of them runs, so AddStep panics when called from a step it runs.
+func (c *MachineContext[Services, State]) AddStep(step Step[Services, State]) string {
+	if c.Machine.concurrent.Load() {
+		panic(fmt.Sprintf("tango: MachineContext.AddStep called on machine %s from a step run by ConcurrentStrategy", c.Machine.Name))
+	}
+	return c.Machine.addStep(step)
 }
 
 // Plugin is an interface that represents a machine plugin.
@@ -18,6 +161,168 @@ type MachineConfig[Services, State any] struct {
 	Log      bool
 	LogLevel string
 	Plugins  []Plugin[Services, State]
+	// StrategyResolver, when set, picks the strategy for each run. It is consulted after the
+	// plugins' ModifyExecutionStrategy hooks, so a non-nil strategy it returns takes precedence.
+	StrategyResolver func(m *Machine[Services, State]) ExecutionStrategy[Services, State]
+	// Reduce, when set, folds each step's response into the state after the step runs.
+	Reduce func(state State, response *Response[Services, State]) State
+	// OnCompensateProgress, when set, is called after each compensate function runs with the
+	// number of steps compensated so far and the number of executed steps to roll back.
+	OnCompensateProgress func(compensated, total int)
+	// OnDeadLetter, when set, is called once a step's failure ends the run, after its retries,
+	// fallbacks and the run's compensation are done. Under RetryStrategy it is only called for
+	// the final failed attempt.
+	OnDeadLetter func(ctx *MachineContext[Services, State], step Step[Services, State], err error)
+	// FlagProvider resolves the feature flags of steps that set FeatureFlag.
+	FlagProvider FlagProvider[Services, State]
+	// WrapError, when set, transforms any error a run returns, including compensation errors,
+	// for example to attach correlation IDs or wrap it in a domain error.
+	WrapError func(err error, ctx *MachineContext[Services, State]) error
+	// SuspendStore persists runs that a step suspends.
+	SuspendStore SuspendStore[State]
+	// History, when set, stores the executed steps instead of Machine.ExecutedSteps, for
+	// example to bound or persist the history of long runs. Compensation walks it in reverse.
+	// A restarted or retried run only clears it if it is a ClearableHistory.
+	History StepHistory[Services, State]
+	// StopCondition, when set, is evaluated after each step of a sequential run. Once it
+	// returns true the run finishes with a DONE response carrying the step's result,
+	// whatever status the step returned.
+	StopCondition func(ctx *MachineContext[Services, State]) bool
+	// Semaphore, when set, is acquired around every step execution. Sharing one Semaphore
+	// between machines caps the steps executing across all of them.
+	Semaphore *Semaphore
+	// Metrics, when set, receives a measurement for every executed and compensated step.
+	Metrics MetricsRecorder
+	// OnResult, when set, is called with each step's response once it becomes the previous
+	// result, whichever strategy runs the steps. With a concurrent strategy it may be called
+	// from several goroutines at once.
+	OnResult func(ctx *MachineContext[Services, State], step string, response *Response[Services, State])
+	// OnProgress, when set, is called each time a step reports progress with
+	// MachineContext.ReportProgress, with the step's name.
+	OnProgress func(ctx *MachineContext[Services, State], step string, fraction float64, message string)
+	// CompensateAllOnError keeps a sequential compensation walking when a step fails to
+	// compensate, or times out, instead of stopping at it. The errors are joined and returned
+	// once the walk ends.
+	CompensateAllOnError bool
+	// TrackSkipped records the steps a SKIP or a forward JUMP passed over, available through
+	// SkippedSteps, separately from the executed steps.
+	TrackSkipped bool
+	// CompensateSkipped, with TrackSkipped, makes a sequential rollback also compensate the
+	// tracked skipped steps, most recent first, once the executed steps are rolled back. Skipped
+	// steps without a Compensate function are left alone. This is meant for steps whose side
+	// effects may have been registered by an earlier run.
+	CompensateSkipped bool
+	// MaxRequeues caps how many times in a row a step may return REQUEUE before the run fails.
+	// Zero means DefaultMaxRequeues.
+	MaxRequeues int
+	// MaxRestarts caps how many times a run may return RESTART before it fails. Zero means
+	// DefaultMaxRestarts.
+	MaxRestarts int
+	// MaxFollowUps caps how many follow-up compensation steps, queued through Response.FollowUp,
+	// a rollback may run before it fails. Zero means DefaultMaxFollowUps.
+	MaxFollowUps int
+	// AutoDoneOnLastStep makes a run whose last step returns NEXT end with a DONE response
+	// carrying that step's result, instead of a nil response.
+	AutoDoneOnLastStep bool
+	// TrackAllocs measures the memory allocated during each run, available as RunOutcome.Allocs.
+	// Reading the allocator statistics briefly stops the world at the start and end of every
+	// run, so it is meant for tuning rather than production.
+	TrackAllocs bool
+	// OnMissingJump decides what a sequential run does when a step jumps to a step that does
+	// not exist. The default, MissingJumpError, fails the run without compensating.
+	OnMissingJump MissingJump
+	// Executor, when set, runs the steps' Execute functions in place of calling them directly,
+	// for example to enforce a timeout or sandbox untrusted steps. It is called for every
+	// attempt, including retries.
+	Executor StepExecutor[Services, State]
+	// InheritServices makes a machine run as a nested machine, returned by RunNewMachine, use the
+	// parent's Services in place of its own. Services is copied by value, so clients held by
+	// pointer or interface are shared with the parent. The nested machine keeps its own State.
+	InheritServices bool
+	// MemoizeAcrossRuns keeps the responses cached for steps that set Memoize from one run to
+	// the next. By default the cache only lives for a run. Reset clears it either way.
+	MemoizeAcrossRuns bool
+	// NewRunID, when set, returns the RunID of each run, for example to use an ID provided by
+	// the caller. By default every run gets a random UUID.
+	NewRunID func() string
+	// StatusHandlers decides how a sequential run continues after a step returns one of the
+	// registered custom statuses. A status that is neither built in nor registered fails the run.
+	StatusHandlers map[ResponseStatus]StatusHandler[Services, State]
+	// AfterRun, when set, is called with the outcome of every run at its very end, after
+	// compensation, plugin cleanup and WrapError. A run that panics is reported with the panic
+	// as its error, and the panic then continues.
+	AfterRun func(outcome RunOutcome[Services, State])
+	// ErrorBudget, when above zero, is how many NonCritical step failures a run tolerates.
+	// Each one uses up one unit and the run continues with the next step. The NonCritical
+	// failure after the budget is used up is handled like the failure of a critical step, so
+	// the run ends and compensates. A critical step's failure always ends the run at once.
+	ErrorBudget int
+	// CloneState, when set, returns a deep copy of a state. Snapshot and Restore use it so that
+	// a checkpoint does not share pointers, maps or slices with the running state. By default
+	// the state is copied by value.
+	CloneState func(state State) State
+	// Tags label the machine for discovery, for example through MachinesByTag once it is
+	// registered. They do not affect execution.
+	Tags []string
+	// AutoUniqueNames appends an incrementing suffix to duplicate step names when steps are added.
+	AutoUniqueNames bool
+	// IsolatePreviousResult sets the PreviousResult of every step ConcurrentStrategy runs to
+	// the result from before the concurrent batch started rather than whichever step happened
+	// to finish last. Each step then runs against its own copy of the context; the changes it
+	// makes to the copy's State are written back when it finishes.
+	IsolatePreviousResult bool
+}
+
+// StepExecutor runs a step's Execute function against ctx and returns its result.
+// DirectExecutor calls it as is.
+type StepExecutor[Services, State any] interface {
+	Execute(ctx *MachineContext[Services, State], step Step[Services, State]) (*Response[Services, State], error)
+}
+
+// DirectExecutor is the StepExecutor machines use by default. It calls the step's Execute
+// function directly.
+type DirectExecutor[Services, State any] struct{}
+
+func (DirectExecutor[Services, State]) Execute(ctx *MachineContext[Services, State], step Step[Services, State]) (*Response[Services, State], error) {
+	return step.Execute(ctx)
+}
+
+// executor returns the StepExecutor that runs the machine's steps.
+func (m *Machine[Services, State]) executor() StepExecutor[Services, State] {
+	if m.Config.Executor != nil {
+		return m.Config.Executor
+	}
+	return DirectExecutor[Services, State]{}
+}
+
+// MetricsRecorder records measurements of the steps a machine runs. The step a measurement is
+// for is identified by its MetricLabel when set, and by its name otherwise. The tangootel
+// module, github.com/phr3nzy/tango/tangootel, provides an OpenTelemetry implementation so that
+// this module does not depend on OpenTelemetry.
+type MetricsRecorder interface {
+	// StepExecuted records a step execution. A step fails when it returns an error or an
+	// ERROR response.
+	StepExecuted(ctx context.Context, machine, step string, duration time.Duration, failed bool)
+	// StepCompensated records a compensated step.
+	StepCompensated(ctx context.Context, machine, step string)
+}
+
+// StatusHandler handles a custom response status. It receives the response and the index of
+// the step that returned it, and returns how the run continues.
+type StatusHandler[Services, State any] func(m *Machine[Services, State], response *Response[Services, State], index int) StatusControl[Services, State]
+
+// StatusControl tells a sequential run how to continue after a custom status. A non-nil Err
+// fails the step, compensating like an ERROR response; otherwise a non-nil Response ends the
+// run with that response; otherwise the run continues at the step at index Next.
+type StatusControl[Services, State any] struct {
+	Next     int
+	Response *Response[Services, State]
+	Err      error
+}
+
+// FlagProvider decides whether a feature flag is enabled for a run.
+type FlagProvider[Services, State any] interface {
+	Enabled(name string, ctx *MachineContext[Services, State]) bool
 }
 
 // Machine is a struct that represents a machine.
@@ -30,6 +335,72 @@ type Machine[Services, State any] struct {
 	Config         *MachineConfig[Services, State]
 	mu             sync.Mutex
 	Strategy       ExecutionStrategy[Services, State]
+	ctx            context.Context
+	cancel         context.CancelFunc
+	done           chan struct{}
+	stopping       atomic.Bool
+	failure        FailureInfo
+	decisions      []Decision
+	start          int
+	fromStep       bool
+	executions     map[string]int
+	plugins        []Plugin[Services, State]
+	responses      []*Response[Services, State]
+	skipped        []Step[Services, State]
+	memo           map[string]*Response[Services, State]
+	errorJumps     []errorJump
+	observe        func(step string, response *Response[Services, State])
+	compensated    bool
+	running        atomic.Bool
+	// concurrent is set while ConcurrentStrategy runs steps in parallel.
+	concurrent   atomic.Bool
+	prepended    int
+	appended     int
+	runResponses []*Response[Services, State]
+
+	// strategyPlugin names the plugin that set the strategy of the current or last run.
+	strategyPlugin string
+	// warnings holds the warnings of the current or last run.
+	warnings []Warning
+	// budgetUsed counts the failures the current run tolerated under its ErrorBudget.
+	budgetUsed int
+	// inFlight holds the steps being executed and when they started.
+	inFlight map[*runningStep]struct{}
+	// graceful holds the in-flight steps that have a grace period.
+	graceful map[*graceStep]struct{}
+	// compensationPlan, when set, replaces the steps' Compensate functions by step name.
+	compensationPlan map[string]func(ctx *MachineContext[Services, State]) (*Response[Services, State], error)
+	// scopedCompensate serializes the compensate functions that run against a copy of the
+	// context, which are those with a CompensateTimeout.
+	scopedCompensate sync.Mutex
+	// followUps holds the compensation steps queued during the current rollback.
+	followUps []Step[Services, State]
+	// allocs is the memory allocated during the last run, when tracked.
+	allocs *AllocStats
+	// compensatedSteps names the steps compensated during the last run, in order.
+	compensatedSteps []string
+	// holdingDeadLetters is set while RetryStrategy runs, so that deadLetter holds failures
+	// until it is known whether the attempt that produced them was the last.
+	holdingDeadLetters bool
+	// heldDeadLetters holds the failures of the current RetryStrategy attempt.
+	heldDeadLetters []heldDeadLetter[Services, State]
+	// failedStep names the step the last run failed at, if any.
+	failedStep string
+	// view is the snapshot returned by View, replaced after each step.
+	view atomic.Pointer[View[Services, State]]
+}
+
+// errorJump is a recovery rule registered with JumpOnError.
+type errorJump struct {
+	target  string
+	matcher func(err error) bool
+}
+
+// FailureInfo describes the failure that triggered compensation during the last run.
+type FailureInfo struct {
+	Step   string
+	Result interface{}
+	Err    error
 }
 
 // NewMachine creates a new machine.
@@ -49,12 +420,80 @@ func NewMachine[Services, State any](
 		Strategy:       strategy,
 	}
 	m.Context.Machine = m
+	if config != nil && config.AutoUniqueNames {
+		m.Steps = nil
+		for _, step := range steps {
+			m.AddStep(step)
+		}
+	}
 	return m
 }
 
-// AddStep adds a step to the machine.
-func (m *Machine[Services, State]) AddStep(step Step[Services, State]) {
-	m.Steps = append(m.Steps, step)
+// Clone returns a machine with the same name, steps, configuration, strategy, compensation
+// plan and JumpOnError rules, and a copy of the initial context. The clone has no execution
+// history. A CloneableStrategy is cloned and a CloneableHistory replaced by an empty one, so
+// the clone can run alongside the machine; other strategies and histories are shared.
+func (m *Machine[Services, State]) Clone() *Machine[Services, State] {
+	initialContext := *m.InitialContext
+	initialContext.PreviousResult = nil
+	initialContext.ctx = nil
+	steps := make([]Step[Services, State], len(m.Steps))
+	copy(steps, m.Steps)
+	for i := range steps {
+		steps[i].Metadata = copyMetadata(steps[i].Metadata)
+	}
+	config := m.Config
+	if config != nil {
+		if history, ok := config.History.(CloneableHistory[Services, State]); ok {
+			copied := *config
+			copied.History = history.Empty()
+			config = &copied
+		}
+	}
+	clone := NewMachine(m.Name, steps, &initialContext, config, cloneStrategy(m.Strategy))
+	clone.compensationPlan = m.compensationPlan
+	clone.errorJumps = slices.Clone(m.errorJumps)
+	return clone
+}
+
+// AddStep adds a step to the machine and returns the name it was registered under.
+// With AutoUniqueNames enabled, a duplicate name gets a "-N" suffix.
+// AddStep panics if the machine is running; steps add steps during a run with
+// MachineContext.AddStep.
+func (m *Machine[Services, State]) AddStep(step Step[Services, State]) string {
+	if m.running.Load() {
+		panic(fmt.Sprintf("tango: AddStep called on machine %s while it is running; use MachineContext.AddStep from a step", m.Name))
+	}
+	return m.addStep(step)
+}
+
+// addStep adds a step after the machine's steps and before any steps appended by plugins.
+func (m *Machine[Services, State]) addStep(step Step[Services, State]) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Config != nil && m.Config.AutoUniqueNames {
+		step.Name = m.uniqueStepName(step.Name)
+	}
+	at := len(m.Steps) - m.appended
+	m.Steps = append(m.Steps[:at], append([]Step[Services, State]{step}, m.Steps[at:]...)...)
+	return step.Name
+}
+
+// uniqueStepName returns name, suffixed with the first free counter if a step already uses it.
+func (m *Machine[Services, State]) uniqueStepName(name string) string {
+	taken := make(map[string]bool, len(m.Steps))
+	for _, s := range m.Steps {
+		taken[s.Name] = true
+	}
+	if !taken[name] {
+		return name
+	}
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
 }
 
 // Reset resets the machine to its initial state. It clears the context and executed steps.
@@ -62,52 +501,356 @@ func (m *Machine[Services, State]) Reset() {
 	m.Steps = nil
 	m.Context = m.InitialContext
 	m.ExecutedSteps = nil
+	m.responses = nil
+	m.decisions = nil
+	m.runResponses = nil
+	m.warnings = nil
+	m.executions = nil
+	m.skipped = nil
+	m.memo = nil
+	m.compensatedSteps = nil
+	m.failedStep = ""
+	m.view.Store(nil)
 }
 
 // Run executes the machine steps.
 func (m *Machine[Services, State]) Run() (*Response[Services, State], error) {
+	return m.RunContext(context.Background())
+}
+
+// RunContext executes the machine steps, stopping before the next step once ctx is cancelled.
+// The context is also used by any compensation triggered during the run.
+func (m *Machine[Services, State]) RunContext(ctx context.Context) (*Response[Services, State], error) {
+	return m.run(ctx, nil)
+}
+
+// RunWith executes the machine steps like Run against a fresh copy of its initial context,
+// whose State overrides modifies first. The machine's own context is left untouched, so a
+// template can be run repeatedly with different per-run state. The copy is shallow: pointers,
+// maps and slices in State are shared with the template.
+func (m *Machine[Services, State]) RunWith(overrides func(state *State)) (*Response[Services, State], error) {
+	return m.runOnCopy(func(ctx *MachineContext[Services, State]) {
+		if overrides != nil {
+			overrides(&ctx.State)
+		}
+	})
+}
+
+// RunWithServices executes the machine steps like Run against a fresh copy of its initial
+// context that uses services in place of the machine's Services, for example to run against a
+// stub client in a test. The machine's own context is left untouched.
+func (m *Machine[Services, State]) RunWithServices(services Services) (*Response[Services, State], error) {
+	return m.runOnCopy(func(ctx *MachineContext[Services, State]) {
+		ctx.Services = services
+	})
+}
+
+// runOnCopy runs the machine against a copy of its initial context, modified by prepare,
+// restoring the machine's contexts afterwards.
+func (m *Machine[Services, State]) runOnCopy(prepare func(ctx *MachineContext[Services, State])) (*Response[Services, State], error) {
+	initial, current := m.InitialContext, m.Context
+	defer func() { m.InitialContext, m.Context = initial, current }()
+
+	runContext := *initial
+	runContext.PreviousResult = nil
+	runContext.ctx = nil
+	prepare(&runContext)
+	m.InitialContext, m.Context = &runContext, &runContext
+	return m.Run()
+}
+
+// RunFrom executes the machine steps starting at the named step instead of the first one.
+// Jumps and skips resolve normally from there. The step is looked up among the machine's own
+// steps: steps plugins prepend are skipped, and steps they append run after the machine's.
+func (m *Machine[Services, State]) RunFrom(stepName string) (*Response[Services, State], error) {
+	index := m.stepIndex(stepName)
+	if index < 0 {
+		return nil, fmt.Errorf("entry step '%s' not found", stepName)
+	}
+	m.start, m.fromStep = index, true
+	defer func() { m.start, m.fromStep = 0, false }()
+	return m.Run()
+}
+
+// run executes the machine steps. A non-nil strategy overrides the one chosen by the
+// plugins and the StrategyResolver.
+func (m *Machine[Services, State]) run(ctx context.Context, strategy ExecutionStrategy[Services, State]) (response *Response[Services, State], err error) {
+	defer func() {
+		if m.Config.AfterRun == nil {
+			return
+		}
+		if r := recover(); r != nil {
+			m.Config.AfterRun(m.outcome(nil, fmt.Errorf("run panicked: %v", r)))
+			panic(r)
+		}
+		m.Config.AfterRun(m.outcome(response, err))
+	}()
+	defer func() {
+		if err != nil && m.Config.WrapError != nil {
+			err = m.Config.WrapError(err, m.Context)
+		}
+	}()
+
+	if m.Config.TrackAllocs {
+		defer m.trackAllocs()()
+	} else {
+		m.allocs = nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	defer close(done)
+	defer cancel()
+
+	// stopping is reset before the run is published, so that the stop request of a Shutdown
+	// that sees the run is not cleared.
+	m.stopping.Store(false)
+	m.mu.Lock()
+	m.ctx, m.cancel, m.done = ctx, cancel, done
+	m.mu.Unlock()
+	m.running.Store(true)
+	defer m.running.Store(false)
+	m.failure = FailureInfo{}
+	m.decisions = nil
+	m.runResponses = nil
+	m.warnings = nil
+	m.executions = make(map[string]int)
+	m.skipped = nil
+	m.compensated = false
+	m.compensatedSteps = nil
+	m.failedStep = ""
+	m.strategyPlugin = ""
+	m.budgetUsed = 0
+	m.Context.RunID = m.newRunID()
+	m.mu.Lock()
+	m.publishView()
+	m.mu.Unlock()
+	if !m.Config.MemoizeAcrossRuns {
+		m.memo = nil
+	}
+
 	if len(m.Steps) == 0 {
 		return nil, fmt.Errorf("no steps to execute")
 	}
 
-	for _, plugin := range m.Config.Plugins {
-		if err := plugin.Init(m.Context); err != nil {
-			return nil, fmt.Errorf("plugin setup error: %v", err)
+	m.plugins = sortPlugins(m.Config.Plugins)
+
+	initialized := 0
+	defer func() {
+		if cleanupErr := m.cleanupPlugins(initialized); cleanupErr != nil {
+			if err == nil {
+				response = nil
+			}
+			err = errors.Join(err, cleanupErr)
+		}
+	}()
+
+	for _, plugin := range m.plugins {
+		if plugin.Init != nil {
+			if err := plugin.Init(m.Context); err != nil {
+				return nil, fmt.Errorf("plugin setup error: %v", err)
+			}
+		}
+		initialized++
+		if plugin.ModifyExecutionStrategy != nil {
+			if newStrategy := plugin.ModifyExecutionStrategy(m); newStrategy != nil {
+				if m.Config.Log {
+					fmt.Printf("[%s] plugin %s set strategy: %s\n", m.Context.RunID, plugin.Name, strategyName(newStrategy))
+				}
+				m.Strategy = newStrategy
+				m.strategyPlugin = plugin.Name
+			}
 		}
-		newStrategy := plugin.ModifyExecutionStrategy(m)
-		if newStrategy != nil {
-			m.Strategy = newStrategy
+	}
+
+	m.contributeSteps()
+	defer func() {
+		m.Steps = m.Steps[m.prepended : len(m.Steps)-m.appended]
+		m.prepended, m.appended = 0, 0
+	}()
+
+	if m.Config.StrategyResolver != nil {
+		if resolved := m.Config.StrategyResolver(m); resolved != nil {
+			m.Strategy = resolved
+			m.strategyPlugin = ""
 		}
 	}
 
-	response, err := m.Strategy.Execute(m)
+	if strategy != nil {
+		m.Strategy = strategy
+		m.strategyPlugin = ""
+	}
+
+	response, err = m.Strategy.Execute(m)
 	if err != nil {
 		return nil, err
 	}
+	if response == nil && m.Config.AutoDoneOnLastStep {
+		response = m.autoDone()
+	}
+
+	return response, nil
+}
+
+// autoDone returns a DONE response with the previous result if the last step the run executed
+// is the machine's last step and it returned NEXT, and nil otherwise.
+func (m *Machine[Services, State]) autoDone() *Response[Services, State] {
+	last := len(m.Steps) - 1
+	for last >= 0 && m.Steps[last].Barrier {
+		last--
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if last < 0 || len(m.decisions) == 0 || m.Context.PreviousResult == nil {
+		return nil
+	}
+	decision := m.decisions[len(m.decisions)-1]
+	if decision.Step != m.Steps[last].Name || decision.Status != NEXT {
+		return nil
+	}
+	return Done[any, Services, State](m.Context.PreviousResult.Result)
+}
+
+// contributeSteps adds the steps contributed by the plugins to the machine's steps for the
+// current run, recording how many were placed on each side so they can be removed after it.
+// A run started from a step with RunFrom or Resume has its start moved past the prepended
+// steps, so it still starts at the step it was given.
+func (m *Machine[Services, State]) contributeSteps() {
+	var prepended, appended []Step[Services, State]
+	for _, plugin := range m.plugins {
+		if plugin.ContributeSteps == nil {
+			continue
+		}
+		contributed := plugin.ContributeSteps(m)
+		if plugin.StepPosition == PrependSteps {
+			prepended = append(prepended, contributed...)
+		} else {
+			appended = append(appended, contributed...)
+		}
+	}
+	m.prepended, m.appended = len(prepended), len(appended)
+	if prepended == nil && appended == nil {
+		return
+	}
+	steps := make([]Step[Services, State], 0, len(prepended)+len(m.Steps)+len(appended))
+	steps = append(steps, prepended...)
+	steps = append(steps, m.Steps...)
+	steps = append(steps, appended...)
+	m.Steps = steps
+	if m.fromStep {
+		m.start += len(prepended)
+	}
+}
 
-	for _, plugin := range m.Config.Plugins {
-		if err := plugin.Cleanup(m.Context); err != nil {
-			return nil, fmt.Errorf("plugin cleanup error: %v", err)
+// cleanupPlugins runs the Cleanup hooks of the first n plugins, whose Init succeeded, in
+// reverse order. Every hook runs even if an earlier one fails.
+func (m *Machine[Services, State]) cleanupPlugins(n int) error {
+	var errs []error
+	for i := n - 1; i >= 0; i-- {
+		if cleanup := m.plugins[i].Cleanup; cleanup != nil {
+			if err := cleanup(m.Context); err != nil {
+				errs = append(errs, fmt.Errorf("plugin cleanup error: %v", err))
+			}
 		}
 	}
+	return errors.Join(errs...)
+}
 
-	return response, nil
+// newRunID returns the ID of a new run.
+func (m *Machine[Services, State]) newRunID() string {
+	if m.Config.NewRunID != nil {
+		return m.Config.NewRunID()
+	}
+	return newUUID()
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("tango: generating run id: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
 // executeStep runs the step and its before and after functions.
-func (m *Machine[Services, State]) executeStep(step Step[Services, State]) (*Response[Services, State], error) {
+func (m *Machine[Services, State]) executeStep(ctx *MachineContext[Services, State], step Step[Services, State]) (response *Response[Services, State], err error) {
+	if m.Config.Metrics != nil {
+		start := time.Now()
+		defer func() {
+			failed := err != nil || response == nil || response.Status == ERROR
+			m.Config.Metrics.StepExecuted(m.runContext(), m.Name, metricLabel(step), time.Since(start), failed)
+		}()
+	}
+
+	if m.Config.Semaphore != nil {
+		held := &slot{semaphore: m.Config.Semaphore}
+		if err := held.acquire(m.runContext()); err != nil {
+			return nil, fmt.Errorf("step %s waiting for semaphore: %w", step.Name, err)
+		}
+		defer held.release()
+		scoped, merge := ctx.withSlot(held)
+		defer merge()
+		ctx = scoped
+	}
+
+	defer m.trackRunning(step.Name)()
+
+	if step.Finally != nil {
+		defer step.Finally(ctx)
+	}
+
+	if step.GracePeriod > 0 {
+		stepCtx, cancel := context.WithCancel(ctx.Context())
+		defer cancel()
+		scoped, merge := ctx.withContext(stepCtx)
+		defer merge()
+		ctx = scoped
+		grace := m.trackGrace(step.GracePeriod, cancel)
+		defer m.untrackGrace(grace)
+		defer func() {
+			if err != nil && grace.expired.Load() {
+				err = fmt.Errorf("step %s stopped after its grace period of %v: %w: %w", step.Name, step.GracePeriod, ErrShutdown, err)
+			}
+		}()
+	}
+
+	if m.subscribesToProgress() {
+		scoped, merge := ctx.withStep(step.Name)
+		defer merge()
+		ctx = scoped
+	}
+
 	if m.Config.Log {
-		fmt.Printf("executing step: %s\n", step.Name)
+		fmt.Printf("[%s] executing step: %s\n", ctx.RunID, step.Name)
 	}
 
-	for _, plugin := range m.Config.Plugins {
-		if err := plugin.Execute(m.Context); err != nil {
+	for _, plugin := range m.plugins {
+		if plugin.Execute == nil {
+			continue
+		}
+		if err := plugin.Execute(ctx); err != nil {
 			return nil, fmt.Errorf("plugin before step error: %v", err)
 		}
 	}
 
+	if step.ExpectInput != nil {
+		if err := checkInput(step, ctx.PreviousResult); err != nil {
+			return nil, err
+		}
+	}
+
+	if step.MapInput != nil {
+		scoped, merge := ctx.scope()
+		defer merge()
+		ctx = scoped
+		ctx.Input = step.MapInput(ctx.PreviousResult)
+	}
+
 	if step.BeforeExecute != nil {
-		if err := step.BeforeExecute(m.Context); err != nil {
+		if err := step.BeforeExecute(ctx); err != nil {
 			return nil, err
 		}
 	}
@@ -116,13 +859,20 @@ func (m *Machine[Services, State]) executeStep(step Step[Services, State]) (*Res
 		return nil, fmt.Errorf("step %s has no execute function", step.Name)
 	}
 
-	response, err := step.Execute(m.Context)
+	response, err = m.executeRepeated(ctx, step)
 	if err != nil {
 		return nil, err
 	}
 
+	if response != nil && response.NewMachine != nil {
+		nested := func() error { return m.runNested(ctx, response.NewMachine) }
+		if err := ctx.slot.yield(m.runContext(), nested); err != nil {
+			return nil, fmt.Errorf("step %s nested machine %s failed: %w", step.Name, response.NewMachine.Name, err)
+		}
+	}
+
 	if step.AfterExecute != nil {
-		if err := step.AfterExecute(m.Context); err != nil {
+		if err := step.AfterExecute(ctx); err != nil {
 			return nil, err
 		}
 	}
@@ -130,9 +880,730 @@ func (m *Machine[Services, State]) executeStep(step Step[Services, State]) (*Res
 	return response, nil
 }
 
+// executeRepeated runs the step's Execute function once, or Repeat times for a repeated step
+// against a copy of ctx whose Iteration is each iteration's index. Changes the iterations make
+// to the copy's State are kept. The repetition stops early at an error or a response other
+// than NEXT, which is returned as is. Otherwise the step's response is NEXT with the
+// iterations' results collected in a []any.
+func (m *Machine[Services, State]) executeRepeated(ctx *MachineContext[Services, State], step Step[Services, State]) (*Response[Services, State], error) {
+	if step.Repeat <= 1 {
+		return m.executeMemoized(ctx, step)
+	}
+	iteration, merge := ctx.scope()
+	defer merge()
+	results := make([]any, 0, step.Repeat)
+	for i := 0; i < step.Repeat; i++ {
+		iteration.Iteration = i
+		response, err := m.executeMemoized(iteration, step)
+		if err != nil || response == nil || response.Status != NEXT {
+			return response, err
+		}
+		results = append(results, response.Result)
+	}
+	return Next[[]any, Services, State](results), nil
+}
+
+// executeMemoized runs the step's Execute function with retries, reusing the cached response
+// when the step is memoized and its key was seen before.
+func (m *Machine[Services, State]) executeMemoized(ctx *MachineContext[Services, State], step Step[Services, State]) (*Response[Services, State], error) {
+	if step.Memoize == nil {
+		return m.executeWithRetry(ctx, step)
+	}
+	key := step.Name + "\x00" + step.Memoize(ctx)
+
+	m.mu.Lock()
+	cached, ok := m.memo[key]
+	m.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	response, err := m.executeWithRetry(ctx, step)
+	if err != nil || response == nil || response.Status == ERROR {
+		return response, err
+	}
+
+	m.mu.Lock()
+	if m.memo == nil {
+		m.memo = make(map[string]*Response[Services, State])
+	}
+	m.memo[key] = response
+	m.mu.Unlock()
+	return response, nil
+}
+
+// runNested runs a nested machine returned by a step on the parent's run context.
+func (m *Machine[Services, State]) runNested(ctx *MachineContext[Services, State], child *Machine[Services, State]) error {
+	if child.Config != nil && child.Config.InheritServices {
+		child.Context.Services = ctx.Services
+	}
+	_, err := child.RunContext(m.runContext())
+	return err
+}
+
+// previousResult returns the context's previous result under the machine's lock.
+func (m *Machine[Services, State]) previousResult() *Response[Services, State] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Context.PreviousResult
+}
+
+// stepContext returns the context a concurrently running step executes against, and the
+// function to call once it is done. That is the machine's own context, unless
+// IsolatePreviousResult is set: the step then runs against a scoped copy holding previous.
+func (m *Machine[Services, State]) stepContext(previous *Response[Services, State]) (*MachineContext[Services, State], func()) {
+	if !m.Config.IsolatePreviousResult {
+		return m.Context, func() {}
+	}
+	ctx, merge := m.Context.scope()
+	ctx.PreviousResult = previous
+	return ctx, merge
+}
+
+// executeWithFallback runs the step and, while the step that just ran failed, runs the next of
+// its alternatives in its place against the same context. It returns the step that produced
+// the final response, which names the fallback when it is not the step itself. Failed attempts
+// are not recorded as results; they are kept on the final response so they are compensated
+// with it.
+func (m *Machine[Services, State]) executeWithFallback(ctx *MachineContext[Services, State], step Step[Services, State]) (Step[Services, State], *Response[Services, State], error) {
+	chain := fallbackChain(&step)
+	response, err := m.executeStep(ctx, step)
+	var attempts []attempt[Services, State]
+	for _, fallback := range chain[1:] {
+		if err == nil && response.Status != ERROR {
+			break
+		}
+		if err == nil {
+			attempts = append(attempts, attempt[Services, State]{step: step, response: response})
+		}
+		step = *fallback
+		response, err = m.executeStep(ctx, step)
+		if response != nil {
+			fallback := *response
+			fallback.Fallback = step.Name
+			fallback.attempts = attempts
+			response = &fallback
+		}
+	}
+	return step, response, err
+}
+
+// fallbackChain returns the step followed by the alternatives tried, in order, while the
+// previous one fails: its Fallback chain, then each of its Fallbacks with their own Fallback
+// chain.
+func fallbackChain[Services, State any](step *Step[Services, State]) []*Step[Services, State] {
+	var chain []*Step[Services, State]
+	for s := step; s != nil; s = s.Fallback {
+		chain = append(chain, s)
+	}
+	for _, fallback := range step.Fallbacks {
+		for s := fallback; s != nil; s = s.Fallback {
+			chain = append(chain, s)
+		}
+	}
+	return chain
+}
+
+// stepEnabled reports whether the step's feature flag, if any, is enabled.
+func (m *Machine[Services, State]) stepEnabled(step Step[Services, State]) bool {
+	if step.FeatureFlag == "" {
+		return true
+	}
+	return m.Config.FlagProvider != nil && m.Config.FlagProvider.Enabled(step.FeatureFlag, m.Context)
+}
+
+// logFailure logs the failure of a non-critical step that the run moves past.
+func (m *Machine[Services, State]) logFailure(step Step[Services, State], err error) {
+	if m.Config.Log {
+		fmt.Printf("[%s] non-critical step failed: %s: %v\n", m.Context.RunID, step.Name, err)
+	}
+}
+
+// tolerate reports whether the run continues past the failure of step, logging the failure
+// if it does. Only non-critical steps are tolerated; with an ErrorBudget, only while the budget
+// lasts, each tolerated failure using up part of it.
+func (m *Machine[Services, State]) tolerate(step Step[Services, State], err error) bool {
+	if !step.NonCritical {
+		return false
+	}
+	if m.Config.ErrorBudget > 0 {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.budgetUsed >= m.Config.ErrorBudget {
+			return false
+		}
+		m.budgetUsed++
+	}
+	m.logFailure(step, err)
+	return true
+}
+
+// budgetExhausted annotates the error of a non-critical step's failure that was not tolerated
+// because the run's error budget ran out.
+func (m *Machine[Services, State]) budgetExhausted(step Step[Services, State], err error) error {
+	if m.Config.ErrorBudget <= 0 || !step.NonCritical {
+		return err
+	}
+	return fmt.Errorf("error budget of %d exhausted: %w", m.Config.ErrorBudget, err)
+}
+
+// heldDeadLetter is a failure deadLetter holds back while RetryStrategy may still retry it.
+type heldDeadLetter[Services, State any] struct {
+	step Step[Services, State]
+	err  error
+}
+
+// deadLetter reports a step whose failure ended the run to the OnDeadLetter hook, or holds it
+// while RetryStrategy may retry the run.
+func (m *Machine[Services, State]) deadLetter(step Step[Services, State], err error) {
+	m.mu.Lock()
+	m.failedStep = step.Name
+	if m.holdingDeadLetters {
+		m.heldDeadLetters = append(m.heldDeadLetters, heldDeadLetter[Services, State]{step: step, err: err})
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+	if m.Config.OnDeadLetter != nil {
+		m.Config.OnDeadLetter(m.Context, step, err)
+	}
+}
+
+// holdDeadLetters makes deadLetter hold the failures it is given, and returns whether it
+// already did, for an enclosing RetryStrategy.
+func (m *Machine[Services, State]) holdDeadLetters(hold bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	held := m.holdingDeadLetters
+	m.holdingDeadLetters = hold
+	return held
+}
+
+// releaseDeadLetters drops the held failures, reporting them through deadLetter if report is
+// set.
+func (m *Machine[Services, State]) releaseDeadLetters(report bool) {
+	m.mu.Lock()
+	letters := m.heldDeadLetters
+	m.heldDeadLetters = nil
+	m.mu.Unlock()
+	if !report {
+		return
+	}
+	for _, letter := range letters {
+		m.deadLetter(letter.step, letter.err)
+	}
+}
+
+// VerifyCompensation checks that every step could be rolled back, returning an error for each
+// step, or fallback, without a Compensate function. It only inspects the definition; nothing
+// is executed or compensated.
+func (m *Machine[Services, State]) VerifyCompensation() []error {
+	var errs []error
+	for _, step := range m.Steps {
+		if step.Barrier {
+			continue
+		}
+		for _, s := range fallbackChain(&step) {
+			if m.compensateFunc(*s) == nil {
+				errs = append(errs, fmt.Errorf("step %s has no compensate function", s.Name))
+			}
+		}
+	}
+	return errs
+}
+
+// ActiveStrategyName returns the type name of the machine's strategy, which after a run is
+// the strategy the run used, for example "ConcurrentStrategy".
+func (m *Machine[Services, State]) ActiveStrategyName() string {
+	return strategyName(m.Strategy)
+}
+
+// StrategyPlugin returns the name of the plugin whose ModifyExecutionStrategy set the strategy
+// of the current or last run, or "" if no plugin did. When several plugins modify the
+// strategy, the last one to run wins and is reported. With Log enabled, every change a plugin
+// makes is logged as well.
+func (m *Machine[Services, State]) StrategyPlugin() string {
+	return m.strategyPlugin
+}
+
+// ValidateWiring checks the declared types along the linear chain of steps: each step's
+// InputType must match the OutputType of the step before it, barriers aside. A step that
+// leaves either type empty, or declares the input type "any", accepts any wiring. Jumps and
+// skips are not followed. It returns an error for each mismatch.
+func (m *Machine[Services, State]) ValidateWiring() []error {
+	var errs []error
+	var previous *Step[Services, State]
+	for i := range m.Steps {
+		step := &m.Steps[i]
+		if step.Barrier {
+			continue
+		}
+		if previous != nil && previous.OutputType != "" && step.InputType != "" && step.InputType != "any" && previous.OutputType != step.InputType {
+			errs = append(errs, fmt.Errorf("step %s expects %s but step %s outputs %s", step.Name, step.InputType, previous.Name, previous.OutputType))
+		}
+		previous = step
+	}
+	return errs
+}
+
+// Healthcheck reports whether the machine is runnable: it has a configuration, a strategy and
+// steps, every step and fallback has an Execute function, step names are unique and every
+// JumpOnError target names a step. It returns every problem found, joined, or nil. Like
+// VerifyCompensation it only inspects the definition.
+func (m *Machine[Services, State]) Healthcheck() error {
+	var errs []error
+	if m.Config == nil {
+		errs = append(errs, fmt.Errorf("machine %s has no config", m.Name))
+	}
+	if m.Strategy == nil {
+		errs = append(errs, fmt.Errorf("machine %s has no strategy", m.Name))
+	}
+	if len(m.Steps) == 0 {
+		errs = append(errs, fmt.Errorf("machine %s has no steps", m.Name))
+	}
+	names := make(map[string]bool, len(m.Steps))
+	for _, step := range m.Steps {
+		if names[step.Name] {
+			errs = append(errs, fmt.Errorf("step name %s is used more than once", step.Name))
+		}
+		names[step.Name] = true
+		if step.Barrier {
+			continue
+		}
+		for _, s := range fallbackChain(&step) {
+			if s.Execute == nil {
+				errs = append(errs, fmt.Errorf("step %s has no execute function", s.Name))
+			}
+		}
+	}
+	for _, jump := range m.errorJumps {
+		if !names[jump.target] {
+			errs = append(errs, fmt.Errorf("jump target '%s' not found", jump.target))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compensateStep runs the step's BeforeCompensate, Compensate and AfterCompensate functions.
+func (m *Machine[Services, State]) compensateStep(step Step[Services, State]) error {
+	step.Compensate = m.compensateFunc(step)
+	if err := m.runCompensate(step); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.compensatedSteps = append(m.compensatedSteps, step.Name)
+	m.mu.Unlock()
+	return nil
+}
+
+// runCompensate runs the step's BeforeCompensate, Compensate and AfterCompensate functions as
+// given, queueing the follow-up steps the compensate response asks for.
+func (m *Machine[Services, State]) runCompensate(step Step[Services, State]) error {
+	if step.BeforeCompensate != nil {
+		if err := step.BeforeCompensate(m.Context); err != nil {
+			return err
+		}
+	}
+	if step.Compensate == nil {
+		return fmt.Errorf("step %s has no compensate function", step.Name)
+	}
+	response, err := m.callCompensate(step)
+	if err != nil {
+		return err
+	}
+	if response != nil && len(response.FollowUp) > 0 {
+		m.mu.Lock()
+		m.followUps = append(m.followUps, response.FollowUp...)
+		m.mu.Unlock()
+	}
+	if step.AfterCompensate != nil {
+		if err := step.AfterCompensate(m.Context); err != nil {
+			return err
+		}
+	}
+	if m.Config.Metrics != nil {
+		m.Config.Metrics.StepCompensated(m.runContext(), m.Name, metricLabel(step))
+	}
+	return nil
+}
+
+// callCompensate runs the step's Compensate function. With a CompensateTimeout, the function
+// runs against a copy of the context whose Context is cancelled once the timeout elapses, and
+// is abandoned then; changes it makes to the copy's State are kept if it returns in time.
+// Those functions hold m.scopedCompensate, so that each copy starts from the State the
+// previous one left.
+func (m *Machine[Services, State]) callCompensate(step Step[Services, State]) (*Response[Services, State], error) {
+	if step.CompensateTimeout <= 0 {
+		return step.Compensate(m.Context)
+	}
+	m.scopedCompensate.Lock()
+	defer m.scopedCompensate.Unlock()
+	compensateCtx, cancel := context.WithTimeout(m.Context.Context(), step.CompensateTimeout)
+	defer cancel()
+	scoped, merge := m.Context.withContext(compensateCtx)
+	type result struct {
+		response *Response[Services, State]
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := step.Compensate(scoped)
+		done <- result{response, err}
+	}()
+	select {
+	case r := <-done:
+		merge()
+		return r.response, r.err
+	case <-compensateCtx.Done():
+		if errors.Is(compensateCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("step %s compensate timed out after %v", step.Name, step.CompensateTimeout)
+		}
+		return nil, fmt.Errorf("step %s compensate interrupted: %w", step.Name, compensateCtx.Err())
+	}
+}
+
+// subscribesToProgress reports whether the configuration or a plugin listens to step progress.
+func (m *Machine[Services, State]) subscribesToProgress() bool {
+	if m.Config.OnProgress != nil {
+		return true
+	}
+	for _, plugin := range m.plugins {
+		if plugin.OnProgress != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// reportProgress passes a step's progress to MachineConfig.OnProgress, then to the plugins'
+// OnProgress hooks.
+func (m *Machine[Services, State]) reportProgress(ctx *MachineContext[Services, State], fraction float64, message string) {
+	if m.Config.OnProgress != nil {
+		m.Config.OnProgress(ctx, ctx.step, fraction, message)
+	}
+	for _, plugin := range m.plugins {
+		if plugin.OnProgress != nil {
+			plugin.OnProgress(ctx, ctx.step, fraction, message)
+		}
+	}
+}
+
+// recordStep appends an executed step to the run history, makes its response the previous
+// result and folds it into the state, then reports the response to the OnResult hooks.
+func (m *Machine[Services, State]) recordStep(step Step[Services, State], response *Response[Services, State]) {
+	m.mu.Lock()
+	m.record(step, response)
+	m.mu.Unlock()
+	if m.Config.OnResult != nil {
+		m.Config.OnResult(m.Context, step.Name, response)
+	}
+	for _, plugin := range m.plugins {
+		if plugin.OnResult != nil {
+			plugin.OnResult(m.Context, step.Name, response)
+		}
+	}
+	if m.observe != nil {
+		m.observe(step.Name, response)
+	}
+}
+
+// record updates the run history and state with an executed step. m.mu must be held.
+func (m *Machine[Services, State]) record(step Step[Services, State], response *Response[Services, State]) {
+	m.history().Append(step, response)
+	m.runResponses = append(m.runResponses, response)
+	m.Context.PreviousResult = response
+	if m.Config.Reduce != nil {
+		m.Context.State = m.Config.Reduce(m.Context.State, response)
+	}
+	m.decisions = append(m.decisions, Decision{
+		Step:       step.Name,
+		Status:     response.Status,
+		SkipCount:  response.SkipCount,
+		JumpTarget: response.JumpTarget,
+		Metadata:   step.Metadata,
+	})
+	if m.executions == nil {
+		m.executions = make(map[string]int)
+	}
+	m.executions[step.Name]++
+	if response.Warning != "" {
+		m.warnings = append(m.warnings, Warning{Step: step.Name, Message: response.Warning})
+	}
+	m.publishView()
+}
+
+// StepExecutionCounts returns how many times each step executed during the last run.
+func (m *Machine[Services, State]) StepExecutionCounts() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[string]int, len(m.executions))
+	for name, count := range m.executions {
+		counts[name] = count
+	}
+	return counts
+}
+
+// JumpOnError registers a recovery rule for sequential runs: when a step fails with an error
+// that matcher accepts, the run jumps to the target step instead of compensating. The error is
+// the one the step returned or, for an ERROR response, its result if that is an error. Rules
+// are tried in the order they were registered and stay in place across runs.
+func (m *Machine[Services, State]) JumpOnError(target string, matcher func(err error) bool) {
+	m.errorJumps = append(m.errorJumps, errorJump{target: target, matcher: matcher})
+}
+
+// recoveryTarget returns the target of the first JumpOnError rule matching err, if any.
+func (m *Machine[Services, State]) recoveryTarget(err error) (string, bool) {
+	for _, jump := range m.errorJumps {
+		if jump.matcher(err) {
+			return jump.target, true
+		}
+	}
+	return "", false
+}
+
+// StepMetadata returns the metadata of the named step, or nil if there is no such step.
+func (m *Machine[Services, State]) StepMetadata(name string) map[string]any {
+	index := m.stepIndex(name)
+	if index < 0 {
+		return nil
+	}
+	return m.Steps[index].Metadata
+}
+
+// copyMetadata returns a shallow copy of metadata.
+func copyMetadata(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		return nil
+	}
+	copied := make(map[string]any, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
+
+// SkippedSteps returns the names of the steps passed over by a SKIP or a forward JUMP during
+// the last run. It is only recorded when MachineConfig.TrackSkipped is set.
+func (m *Machine[Services, State]) SkippedSteps() []string {
+	names := make([]string, 0, len(m.skipped))
+	for _, step := range m.skipped {
+		names = append(names, step.Name)
+	}
+	return names
+}
+
+// CompensatedSteps returns the names of the steps compensated during the last run, in the
+// order their compensate functions completed. A step whose compensation failed is left out.
+func (m *Machine[Services, State]) CompensatedSteps() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.compensatedSteps...)
+}
+
+// trackSkipped records the steps between from and to, exclusive of to, as skipped.
+func (m *Machine[Services, State]) trackSkipped(from, to int) {
+	if !m.Config.TrackSkipped {
+		return
+	}
+	for i := from; i < to && i < len(m.Steps); i++ {
+		m.skipped = append(m.skipped, m.Steps[i])
+	}
+}
+
+// stepIndex returns the index of the step with the given name, or -1.
+func (m *Machine[Services, State]) stepIndex(name string) int {
+	for index, s := range m.Steps {
+		if s.Name == name {
+			return index
+		}
+	}
+	return -1
+}
+
+// checkInput verifies the previous result matches the type the step expects.
+func checkInput[Services, State any](step Step[Services, State], previous *Response[Services, State]) error {
+	if previous == nil || previous.Result == nil {
+		return fmt.Errorf("step %s expects input of type %v, got no previous result", step.Name, step.ExpectInput)
+	}
+	if !reflect.TypeOf(previous.Result).AssignableTo(step.ExpectInput) {
+		return fmt.Errorf("step %s expects input of type %v, got %T", step.Name, step.ExpectInput, previous.Result)
+	}
+	return nil
+}
+
 // Compensate runs the compensate functions of the executed steps.
 func (m *Machine[Services, State]) Compensate() (*Response[Services, State], error) {
-	return m.Strategy.Compensate(m)
+	m.compensated = true
+	return m.rollback()
+}
+
+// CompensateContext runs the compensate functions of the executed steps, stopping the
+// reverse walk once ctx is cancelled. An interrupted walk returns a *CompensationError. The
+// machine's own context is restored once the walk ends.
+func (m *Machine[Services, State]) CompensateContext(ctx context.Context) (*Response[Services, State], error) {
+	m.mu.Lock()
+	previous := m.ctx
+	m.ctx = ctx
+	m.compensated = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.ctx = previous
+		m.mu.Unlock()
+	}()
+	return m.rollback()
+}
+
+// Shutdown stops the running machine from launching new steps and waits for the in-flight
+// steps to finish, after which the run compensates and returns ErrShutdown. If ctx ends first,
+// the run's context is cancelled and ctx's error is returned. An in-flight step with a
+// GracePeriod has its own context cancelled once its grace period elapses.
+func (m *Machine[Services, State]) Shutdown(ctx context.Context) error {
+	m.stopping.Store(true)
+
+	m.mu.Lock()
+	done, cancel := m.done, m.cancel
+	for grace := range m.graceful {
+		grace.start()
+	}
+	m.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// runningStep is a step being executed.
+type runningStep struct {
+	name    string
+	started time.Time
+}
+
+// trackRunning registers a step as being executed and returns the function that unregisters
+// it once it is done.
+func (m *Machine[Services, State]) trackRunning(name string) func() {
+	step := &runningStep{name: name, started: time.Now()}
+	m.mu.Lock()
+	if m.inFlight == nil {
+		m.inFlight = make(map[*runningStep]struct{})
+	}
+	m.inFlight[step] = struct{}{}
+	m.mu.Unlock()
+	return func() {
+		m.mu.Lock()
+		delete(m.inFlight, step)
+		m.mu.Unlock()
+	}
+}
+
+// CurrentStep returns the name of the step being executed and when it started, or ok false
+// if no step is running. When several steps run concurrently, it returns the one that has
+// been running the longest. It is safe to call from any goroutine, for example to detect a
+// stuck step.
+func (m *Machine[Services, State]) CurrentStep() (name string, started time.Time, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for step := range m.inFlight {
+		if !ok || step.started.Before(started) {
+			name, started, ok = step.name, step.started, true
+		}
+	}
+	return name, started, ok
+}
+
+// graceStep is an in-flight step with a grace period.
+type graceStep struct {
+	period  time.Duration
+	cancel  context.CancelFunc
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// start cancels the step once its grace period elapses. m.mu must be held.
+func (g *graceStep) start() {
+	if g.timer == nil {
+		g.timer = time.AfterFunc(g.period, func() {
+			g.expired.Store(true)
+			g.cancel()
+		})
+	}
+}
+
+// trackGrace registers an in-flight step whose context cancel cancels after period once the
+// machine shuts down.
+func (m *Machine[Services, State]) trackGrace(period time.Duration, cancel context.CancelFunc) *graceStep {
+	grace := &graceStep{period: period, cancel: cancel}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.graceful == nil {
+		m.graceful = make(map[*graceStep]struct{})
+	}
+	m.graceful[grace] = struct{}{}
+	if m.stopping.Load() {
+		grace.start()
+	}
+	return grace
+}
+
+// untrackGrace unregisters a step registered with trackGrace once it is done.
+func (m *Machine[Services, State]) untrackGrace(grace *graceStep) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.graceful, grace)
+	if grace.timer != nil {
+		grace.timer.Stop()
+	}
+}
+
+// checkStop reports why step must not be started, if the run was cancelled or shut down.
+func (m *Machine[Services, State]) checkStop(step Step[Services, State]) error {
+	if m.stopping.Load() {
+		return fmt.Errorf("machine %s stopped before step %s: %w", m.Name, step.Name, ErrShutdown)
+	}
+	if err := m.runContext().Err(); err != nil {
+		return fmt.Errorf("machine %s stopped before step %s: %w", m.Name, step.Name, err)
+	}
+	return nil
+}
+
+// stop ends a run that checkStop interrupted. A shut down run compensates its executed steps
+// on an uncancelled context; a cancelled run leaves them as they are.
+func (m *Machine[Services, State]) stop(stopErr error) (*Response[Services, State], error) {
+	if !errors.Is(stopErr, ErrShutdown) {
+		return nil, stopErr
+	}
+	m.setRunContext(context.WithoutCancel(m.runContext()))
+	m.failure = FailureInfo{Err: stopErr}
+	cResponse, err := m.Compensate()
+	if err != nil {
+		return nil, fmt.Errorf("compensate error: %w", err)
+	}
+	return cResponse, stopErr
+}
+
+// runContext returns the context of the current run or compensation.
+func (m *Machine[Services, State]) runContext() context.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// setRunContext replaces the context of the current run or compensation.
+func (m *Machine[Services, State]) setRunContext(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ctx = ctx
 }
 
 // Result is an alias for any.
@@ -148,6 +1619,11 @@ func (m *Machine[Services, State]) Next(result Result) *Response[Services, State
 	return Next[Result, Services, State](result)
 }
 
+// NextWithWarning creates a response with status NEXT that carries a warning.
+func (m *Machine[Services, State]) NextWithWarning(result Result, warning string) *Response[Services, State] {
+	return NextWithWarning[Result, Services, State](result, warning)
+}
+
 // Done creates a response with status DONE.
 func (m *Machine[Services, State]) Done(result Result) *Response[Services, State] {
 	return Done[Result, Services, State](result)
@@ -163,6 +1639,27 @@ func (m *Machine[Services, State]) Skip(result Result, count int) *Response[Serv
 	return Skip[Result, Services, State](result, count)
 }
 
+// Suspend creates a response with status SUSPEND.
+func (m *Machine[Services, State]) Suspend(result Result) *Response[Services, State] {
+	return Suspend[Result, Services, State](result)
+}
+
+// Requeue creates a response with status REQUEUE, which runs the same step again after the
+// given delay instead of advancing.
+func (m *Machine[Services, State]) Requeue(result Result, after time.Duration) *Response[Services, State] {
+	return Requeue[Result, Services, State](result, after)
+}
+
+// Savepoint creates a response with status SAVEPOINT.
+func (m *Machine[Services, State]) Savepoint(result Result) *Response[Services, State] {
+	return Savepoint[Result, Services, State](result)
+}
+
+// Restart creates a response with status RESTART.
+func (m *Machine[Services, State]) Restart(result Result) *Response[Services, State] {
+	return Restart[Result, Services, State](result)
+}
+
 // Jump creates a response with status JUMP.
 func (m *Machine[Services, State]) Jump(result any, target string) *Response[Services, State] {
 	return Jump[Result, Services, State](result, target)
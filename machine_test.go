@@ -1,10 +1,20 @@
 package tango_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/phr3nzy/tango"
+	"github.com/phr3nzy/tango/tangotest"
 )
 
 type Services struct {
@@ -231,470 +241,1878 @@ func TestMachine_Compensate_State(t *testing.T) {
 				}
 			}
 
-			if m.Context.State.Counter != 0 {
-				t.Errorf("expected state counter to be 0, got %v", m.Context.State.Counter)
-			}
+			tangotest.AssertStateRestored(t, State{Counter: 0}, m.Context.State)
 
 		})
 	}
 }
 
-type resetTestCase struct {
-	name              string
-	steps             []tango.Step[Services, State]
-	expectedSteps     int
-	expectedExecSteps int
+type compensateContextTestCase struct {
+	name                string
+	cancelDuring        string
+	expectedCompensated []string
+	expectedPending     []string
 }
 
-func TestMachine_Reset(t *testing.T) {
-	tests := []resetTestCase{
+func TestMachine_CompensateContext(t *testing.T) {
+	tests := []compensateContextTestCase{
 		{
-			name: "ResetAfterRun",
-			steps: []tango.Step[Services, State]{
+			name:                "CancelMidCompensation",
+			cancelDuring:        "Step3",
+			expectedCompensated: []string{"Step3"},
+			expectedPending:     []string{"Step2", "Step1"},
+		},
+		{
+			name:                "CancelOnLastCompensation",
+			cancelDuring:        "Step1",
+			expectedCompensated: []string{"Step3", "Step2", "Step1"},
+			expectedPending:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var compensated []string
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					if name == tt.cancelDuring {
+						cancel()
+					}
+					return ctx.Machine.Done(nil), nil
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
 				{
 					Name: "Step1",
 					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
 						return ctx.Machine.Next("Next"), nil
 					},
+					Compensate: compensate("Step1"),
 				},
 				{
 					Name: "Step2",
 					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						return ctx.Machine.Done("Done"), nil
+						return ctx.Machine.Next("Next"), nil
 					},
+					Compensate: compensate("Step2"),
 				},
-			},
-			expectedSteps:     0,
-			expectedExecSteps: 0,
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("I will be compensated"), nil
+					},
+					Compensate: compensate("Step3"),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, err := m.RunContext(ctx)
+
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensate functions %v to run, got %v", tt.expectedCompensated, compensated)
+			}
+
+			var compErr *tango.CompensationError
+			if tt.expectedPending == nil {
+				if errors.As(err, &compErr) {
+					t.Errorf("expected compensation to complete, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &compErr) {
+				t.Fatalf("expected a compensation error, got %v", err)
+			}
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected error to wrap context.Canceled, got %v", err)
+			}
+			if !reflect.DeepEqual(compErr.Compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compErr.Compensated)
+			}
+			if !reflect.DeepEqual(compErr.Pending, tt.expectedPending) {
+				t.Errorf("expected pending steps %v, got %v", tt.expectedPending, compErr.Pending)
+			}
+		})
+	}
+}
+
+type compensateContextRestoreTestCase struct {
+	name                string
+	expectedPending     []string
+	expectedCompensated []string
+}
+
+func TestMachine_CompensateContext_RestoresContext(t *testing.T) {
+	tests := []compensateContextRestoreTestCase{
+		{
+			name:                "LaterCompensateNotCancelled",
+			expectedPending:     []string{"Step2", "Step1"},
+			expectedCompensated: []string{"Step2", "Step1"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			context := &tango.MachineContext[Services, State]{}
-			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
-				Log: false,
-			}, &tango.SequentialStrategy[Services, State]{})
-			context.Machine = m
-
-			for _, step := range tt.steps {
-				m.AddStep(step)
+			var compensated []string
+			step := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(name), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, name)
+						return nil, nil
+					},
+				}
 			}
 
-			_, _ = m.Run()
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{step("Step1"), step("Step2")},
+				&tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+			m.ExecutedSteps = append(m.ExecutedSteps, m.Steps...)
 
-			m.Reset()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := m.CompensateContext(ctx)
+			var compensationErr *tango.CompensationError
+			if !errors.As(err, &compensationErr) {
+				t.Fatalf("expected a *tango.CompensationError, got %v", err)
+			}
+			if !reflect.DeepEqual(compensationErr.Pending, tt.expectedPending) {
+				t.Errorf("expected pending steps %v, got %v", tt.expectedPending, compensationErr.Pending)
+			}
 
-			if len(m.Steps) != tt.expectedSteps {
-				t.Errorf("expected steps to be %v, got %v", tt.expectedSteps, len(m.Steps))
+			if _, err := m.Compensate(); err != nil {
+				t.Fatalf("expected Compensate to run on the machine's own context, got %v", err)
 			}
-			if len(m.ExecutedSteps) != tt.expectedExecSteps {
-				t.Errorf("expected executed steps to be %v, got %v", tt.expectedExecSteps, len(m.ExecutedSteps))
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
 			}
 		})
 	}
 }
 
-type stateTestCase struct {
-	name            string
-	initialState    State
-	steps           []tango.Step[Services, State]
-	expectedCounter int
+type expectInputTestCase struct {
+	name          string
+	firstResult   any
+	expectInput   reflect.Type
+	expectedError string
 }
 
-func TestMachine_Context_State(t *testing.T) {
-	tests := []stateTestCase{
+func TestMachine_Step_ExpectInput(t *testing.T) {
+	tests := []expectInputTestCase{
 		{
-			name:         "IncrementCounter",
-			initialState: State{Counter: 0},
-			steps: []tango.Step[Services, State]{
+			name:          "MatchingInput",
+			firstResult:   "page",
+			expectInput:   reflect.TypeOf(""),
+			expectedError: "",
+		},
+		{
+			name:          "MismatchedInput",
+			firstResult:   42,
+			expectInput:   reflect.TypeOf(""),
+			expectedError: "step Step2 expects input of type string, got int",
+		},
+		{
+			name:          "MissingInput",
+			firstResult:   nil,
+			expectInput:   reflect.TypeOf(""),
+			expectedError: "step Step2 expects input of type string, got no previous result",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
 				{
 					Name: "Step1",
 					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						ctx.State.Counter++
-						return ctx.Machine.Next("Next"), nil
+						return ctx.Machine.Next(tt.firstResult), nil
 					},
 				},
 				{
-					Name: "Step2",
+					Name:        "Step2",
+					ExpectInput: tt.expectInput,
 					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						ctx.State.Counter++
-						return ctx.Machine.Done("Done"), nil
+						return ctx.Machine.Done(ctx.PreviousResult.Result.(string)), nil
 					},
 				},
-			},
-			expectedCounter: 2,
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, err := m.Run()
+
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+type concurrentStepErrorTestCase struct {
+	name              string
+	failing           string
+	expectedError     string
+	expectCompensated bool
+}
+
+func TestMachine_ConcurrentStrategy_StepError(t *testing.T) {
+	tests := []concurrentStepErrorTestCase{
+		{
+			name: "NoFailureNoCompensation",
+		},
+		{
+			name:              "StepErrorReturned",
+			failing:           "Step2",
+			expectedError:     "step failed",
+			expectCompensated: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			context := &tango.MachineContext[Services, State]{State: tt.initialState}
-			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
-				Log: false,
-			}, &tango.SequentialStrategy[Services, State]{})
-			context.Machine = m
-
-			for _, step := range tt.steps {
-				m.AddStep(step)
+			var compensated atomic.Bool
+			step := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if name == tt.failing {
+							return nil, errors.New("step failed")
+						}
+						return ctx.Machine.Next(name), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated.Store(true)
+						return nil, nil
+					},
+				}
 			}
 
-			_, _ = m.Run()
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{step("Step1"), step("Step2")},
+				&tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{},
+				&tango.ConcurrentStrategy[Services, State]{Concurrency: 2})
 
-			if m.Context.State.Counter != tt.expectedCounter {
-				t.Errorf("expected state counter to be %v, got %v", tt.expectedCounter, m.Context.State.Counter)
+			_, err := m.Run()
+			if tt.expectedError == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedError != "" && (err == nil || err.Error() != tt.expectedError) {
+				t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+			}
+			if compensated.Load() != tt.expectCompensated {
+				t.Errorf("expected compensated %v, got %v", tt.expectCompensated, compensated.Load())
 			}
 		})
 	}
 }
 
-type servicesTestCase struct {
-	name             string
-	initialServices  Services
-	steps            []tango.Step[Services, State]
-	expectedDatabase string
+type shutdownTestCase struct {
+	name            string
+	steps           int
+	concurrency     int
+	expectedStarted int32
 }
 
-func TestMachine_Context_Services(t *testing.T) {
-	tests := []servicesTestCase{
+func TestMachine_Shutdown(t *testing.T) {
+	tests := []shutdownTestCase{
 		{
-			name:            "ChangeDatabaseService",
-			initialServices: Services{Database: "MySQL"},
-			steps: []tango.Step[Services, State]{
-				{
-					Name: "Step1",
-					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						ctx.Services.Database = "PostgreSQL"
-						return ctx.Machine.Next("Next"), nil
-					},
-				},
-				{
-					Name: "Step2",
-					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						ctx.Services.Database = "SQLite"
-						return ctx.Machine.Done("Done"), nil
-					},
-				},
-			},
-			expectedDatabase: "SQLite",
+			name:            "DrainInFlightSteps",
+			steps:           20,
+			concurrency:     2,
+			expectedStarted: 2,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			context := &tango.MachineContext[Services, State]{Services: tt.initialServices}
-			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
+			var started, compensated atomic.Int32
+			inFlight := make(chan struct{}, tt.steps)
+			release := make(chan struct{})
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
 				Log: false,
-			}, &tango.SequentialStrategy[Services, State]{})
-			context.Machine = m
+			}, &tango.ConcurrentStrategy[Services, State]{Concurrency: tt.concurrency})
 
-			for _, step := range tt.steps {
-				m.AddStep(step)
+			for i := 0; i < tt.steps; i++ {
+				m.AddStep(tango.Step[Services, State]{
+					Name: fmt.Sprintf("Step%d", i),
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						started.Add(1)
+						inFlight <- struct{}{}
+						<-release
+						return ctx.Machine.Next("Next"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated.Add(1)
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				})
 			}
 
-			_, _ = m.Run()
+			runErr := make(chan error, 1)
+			go func() {
+				_, err := m.Run()
+				runErr <- err
+			}()
 
-			if m.Context.Services.Database != tt.expectedDatabase {
-				t.Errorf("expected services database to be %v, got %v", tt.expectedDatabase, m.Context.Services.Database)
+			for i := 0; i < tt.concurrency; i++ {
+				<-inFlight
+			}
+
+			shutdownErr := make(chan error, 1)
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				shutdownErr <- m.Shutdown(ctx)
+			}()
+
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+
+			if err := <-shutdownErr; err != nil {
+				t.Errorf("unexpected shutdown error: %v", err)
+			}
+			if err := <-runErr; !errors.Is(err, tango.ErrShutdown) {
+				t.Errorf("expected error %v, got %v", tango.ErrShutdown, err)
+			}
+			if started.Load() != tt.expectedStarted {
+				t.Errorf("expected %v started steps, got %v", tt.expectedStarted, started.Load())
+			}
+			if compensated.Load() != tt.expectedStarted {
+				t.Errorf("expected %v compensated steps, got %v", tt.expectedStarted, compensated.Load())
 			}
 		})
 	}
 }
 
-type jumpTestCase struct {
-	name              string
-	steps             []tango.Step[Services, State]
-	expectedResult    string
-	expectedError     error
-	expectedStepNames []string
+type gracePeriodTestCase struct {
+	name        string
+	grace       map[string]time.Duration
+	work        time.Duration
+	expectedCut map[string]bool
 }
 
-func TestMachine_Step_Jump(t *testing.T) {
-	tests := []jumpTestCase{
+func TestMachine_Step_GracePeriod(t *testing.T) {
+	tests := []gracePeriodTestCase{
 		{
-			name: "JumpToStep3",
-			steps: []tango.Step[Services, State]{
-				{
-					Name: "Step1",
-					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						return ctx.Machine.Jump("Jump", "Step3"), nil
-					},
-				},
-				{
-					Name: "Step2",
-					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						return ctx.Machine.Error("I got skipped"), nil
-					},
-				},
-				{
-					Name: "Step3",
-					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						return ctx.Machine.Done("Done"), nil
-					},
-				},
-			},
-			expectedResult:    "Done",
-			expectedError:     nil,
-			expectedStepNames: []string{"Step1", "Step3"},
+			name:        "LongGraceFinishes",
+			grace:       map[string]time.Duration{"WriteDatabase": time.Second, "WarmCache": 10 * time.Millisecond},
+			work:        100 * time.Millisecond,
+			expectedCut: map[string]bool{"WriteDatabase": false, "WarmCache": true},
 		},
-		// Add more test cases as needed
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			context := &tango.MachineContext[Services, State]{}
-			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
-				Log: false,
-			}, &tango.SequentialStrategy[Services, State]{})
-			context.Machine = m
-
-			for _, step := range tt.steps {
-				m.AddStep(step)
+			var mu sync.Mutex
+			cut := make(map[string]bool)
+			inFlight := make(chan struct{}, len(tt.grace))
+
+			var steps []tango.Step[Services, State]
+			for name, grace := range tt.grace {
+				steps = append(steps, tango.Step[Services, State]{
+					Name:        name,
+					GracePeriod: grace,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						inFlight <- struct{}{}
+						err := ctx.Sleep(tt.work)
+						mu.Lock()
+						cut[name] = err != nil
+						mu.Unlock()
+						if err != nil {
+							return nil, err
+						}
+						return ctx.Machine.Next(nil), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return nil, nil
+					},
+				})
 			}
 
-			response, err := m.Run()
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: len(steps)})
 
-			if tt.expectedError != nil {
-				if err == nil || err.Error() != tt.expectedError.Error() {
-					t.Errorf("expected error %v, got %v", tt.expectedError, err)
-				}
-			} else if err != nil {
-				t.Errorf("unexpected error: %v", err)
+			runErr := make(chan error, 1)
+			go func() {
+				_, err := m.Run()
+				runErr <- err
+			}()
+			for range steps {
+				<-inFlight
 			}
 
-			if response == nil {
-				t.Errorf("expected response to be non-nil")
-			} else if response.Result != tt.expectedResult {
-				t.Errorf("expected result to be %v, got %v", tt.expectedResult, response.Result)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := m.Shutdown(ctx); err != nil {
+				t.Errorf("unexpected shutdown error: %v", err)
 			}
-
-			if len(m.ExecutedSteps) != len(tt.expectedStepNames) {
-				t.Errorf("expected %v executed steps, got %v", len(tt.expectedStepNames), len(m.ExecutedSteps))
+			if err := <-runErr; !errors.Is(err, tango.ErrShutdown) {
+				t.Errorf("expected error %v, got %v", tango.ErrShutdown, err)
 			}
-
-			for i, step := range m.ExecutedSteps {
-				if step.Name != tt.expectedStepNames[i] {
-					t.Errorf("expected step %v, got %v", tt.expectedStepNames[i], step.Name)
-				}
+			if !reflect.DeepEqual(cut, tt.expectedCut) {
+				t.Errorf("expected cut steps %v, got %v", tt.expectedCut, cut)
 			}
 		})
 	}
 }
 
-type stepSkipTestCase struct {
-	name                  string
-	steps                 []tango.Step[Services, State]
-	expectedExecutedSteps []string
-	expectedResult        string
-	expectedError         error
+type compensateIfTestCase struct {
+	name                string
+	failure             string
+	expectedCompensated []string
 }
 
-func TestMachine_Step_Skip(t *testing.T) {
-	tests := []stepSkipTestCase{
+func TestMachine_Step_CompensateIf(t *testing.T) {
+	tests := []compensateIfTestCase{
 		{
-			name: "SkipStep",
-			steps: []tango.Step[Services, State]{
+			name:                "UserCancelSkipsCompensation",
+			failure:             "user-cancel",
+			expectedCompensated: []string{"Step2"},
+		},
+		{
+			name:                "RealErrorCompensates",
+			failure:             "connection reset",
+			expectedCompensated: []string{"Step2", "Step1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compensated []string
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
 				{
 					Name: "Step1",
 					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						return ctx.Machine.Skip("Skip", 1), nil
+						return ctx.Machine.Next("Next"), nil
+					},
+					CompensateIf: func(failure tango.FailureInfo) bool {
+						return failure.Result != "user-cancel"
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, "Step1")
+						return ctx.Machine.Done("Compensated"), nil
 					},
 				},
 				{
 					Name: "Step2",
 					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-						return ctx.Machine.Error("I will be skipped"), nil
+						return ctx.Machine.Error(tt.failure), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, "Step2")
+						return ctx.Machine.Done("Compensated"), nil
 					},
 				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, err := m.Run()
+
+			expectedError := "step Step2 failed: " + tt.failure
+			if err == nil || err.Error() != expectedError {
+				t.Errorf("expected error %v, got %v", expectedError, err)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+type strategyResolverTestCase struct {
+	name             string
+	machineName      string
+	expectConcurrent bool
+}
+
+func TestMachine_StrategyResolver(t *testing.T) {
+	tests := []strategyResolverTestCase{
+		{
+			name:             "ResolverSwapsStrategy",
+			machineName:      "fan-out",
+			expectConcurrent: true,
+		},
+		{
+			name:             "ResolverKeepsStrategy",
+			machineName:      "TestMachine",
+			expectConcurrent: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine(tt.machineName, []tango.Step[Services, State]{
 				{
-					Name: "Step3",
+					Name: "Step1",
 					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
 						return ctx.Machine.Done("Done"), nil
 					},
 				},
-			},
-			expectedExecutedSteps: []string{"Step1", "Step3"},
-			expectedResult:        "Done",
-			expectedError:         nil,
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+				StrategyResolver: func(m *tango.Machine[Services, State]) tango.ExecutionStrategy[Services, State] {
+					if m.Name == "fan-out" {
+						return &tango.ConcurrentStrategy[Services, State]{Concurrency: 4}
+					}
+					return nil
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			_, concurrent := m.Strategy.(*tango.ConcurrentStrategy[Services, State])
+			if concurrent != tt.expectConcurrent {
+				t.Errorf("expected concurrent strategy %v, got %T", tt.expectConcurrent, m.Strategy)
+			}
+		})
+	}
+}
+
+type fallbackTestCase struct {
+	name                string
+	secondaryFails      bool
+	expectedResult      any
+	expectedError       string
+	expectedStepNames   []string
+	expectedCounts      map[string]int
+	expectedCompensated []string
+}
+
+func TestMachine_Step_Fallback(t *testing.T) {
+	tests := []fallbackTestCase{
+		{
+			name:              "FallbackCompletesRun",
+			secondaryFails:    false,
+			expectedResult:    "from secondary",
+			expectedStepNames: []string{"fetch-secondary"},
+			expectedCounts:    map[string]int{"fetch-secondary": 1},
+		},
+		{
+			name:                "FallbackFailsToo",
+			secondaryFails:      true,
+			expectedError:       "step fetch-secondary failed: secondary unavailable",
+			expectedStepNames:   []string{"fetch-secondary"},
+			expectedCounts:      map[string]int{"fetch-secondary": 1},
+			expectedCompensated: []string{"fetch-secondary", "fetch-primary"},
 		},
-		// Add more test cases as needed
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			context := &tango.MachineContext[Services, State]{}
-			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
+			var compensated []string
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					return ctx.Machine.Done("Compensated"), nil
+				}
+			}
+
+			var fallbackInput any
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "fetch-primary",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("primary unavailable"), nil
+					},
+					Compensate: compensate("fetch-primary"),
+					Fallback: &tango.Step[Services, State]{
+						Name: "fetch-secondary",
+						Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+							fallbackInput = ctx.PreviousResult.Result
+							if tt.secondaryFails {
+								return ctx.Machine.Error("secondary unavailable"), nil
+							}
+							return ctx.Machine.Done("from secondary"), nil
+						},
+						Compensate: compensate("fetch-secondary"),
+					},
+				},
+			}, &tango.MachineContext[Services, State]{
+				PreviousResult: &tango.Response[Services, State]{Result: "order", Status: tango.NEXT},
+			}, &tango.MachineConfig[Services, State]{
 				Log: false,
 			}, &tango.SequentialStrategy[Services, State]{})
-			context.Machine = m
-
-			for _, step := range tt.steps {
-				m.AddStep(step)
-			}
 
 			response, err := m.Run()
 
-			if err != tt.expectedError {
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
 				t.Errorf("unexpected error: %v", err)
-			}
-			if response == nil {
-				t.Errorf("expected response to be non-nil, got nil")
-			} else if response.Result != tt.expectedResult {
-				t.Errorf("expected result to be %v, got %v", tt.expectedResult, response.Result)
+			} else if response == nil || response.Result != tt.expectedResult {
+				t.Errorf("expected result to be %v, got %v", tt.expectedResult, response)
 			}
 
-			executedStepNames := []string{}
+			var stepNames []string
 			for _, step := range m.ExecutedSteps {
-				executedStepNames = append(executedStepNames, step.Name)
+				stepNames = append(stepNames, step.Name)
 			}
-			if len(executedStepNames) != len(tt.expectedExecutedSteps) {
-				t.Errorf("expected executed steps to be %v, got %v", tt.expectedExecutedSteps, executedStepNames)
+			if !reflect.DeepEqual(stepNames, tt.expectedStepNames) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedStepNames, stepNames)
+			}
+			if counts := m.StepExecutionCounts(); !reflect.DeepEqual(counts, tt.expectedCounts) {
+				t.Errorf("expected execution counts %v, got %v", tt.expectedCounts, counts)
+			}
+			if fallbackInput != "order" {
+				t.Errorf("expected the fallback to see the original previous result, got %v", fallbackInput)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
 			}
 		})
 	}
 }
 
-func BenchmarkMachine_Run_Sequential(b *testing.B) {
-	// Create a new machine
-	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
-		Log: false,
-	}, &tango.SequentialStrategy[Services, State]{})
+type fallbacksTestCase struct {
+	name                string
+	working             string
+	expectedResult      any
+	expectedFallback    string
+	expectedStepNames   []string
+	expectedCompensated []string
+	expectErr           bool
+}
 
-	// Add some steps to the machine
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step1",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Next("Next"), nil
+func TestMachine_Step_Fallbacks(t *testing.T) {
+	tests := []fallbacksTestCase{
+		{
+			name:              "ThirdFallbackCompletesRun",
+			working:           "tertiary",
+			expectedResult:    "from tertiary",
+			expectedFallback:  "tertiary",
+			expectedStepNames: []string{"tertiary"},
 		},
-	})
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step2",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Done("Done"), nil
+		{
+			name:              "PrimarySucceeds",
+			working:           "primary",
+			expectedResult:    "from primary",
+			expectedStepNames: []string{"primary"},
+		},
+		{
+			name:                "AllFail",
+			expectedStepNames:   []string{"tertiary"},
+			expectedCompensated: []string{"tertiary", "cache", "secondary", "primary"},
+			expectErr:           true,
 		},
-	})
-
-	// Run the machine
-	for i := 0; i < b.N; i++ {
-		_, _ = m.Run()
 	}
-}
-
-func BenchmarkMachine_Run_Concurrent(b *testing.B) {
-	// Create a new machine
-	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
-		Log: false,
-	}, &tango.ConcurrentStrategy[Services, State]{})
 
-	// Add some steps to the machine
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step1",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Next("Next"), nil
-		},
-	})
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step2",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Done("Done"), nil
-		},
-	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compensated []string
+			source := func(name string) *tango.Step[Services, State] {
+				return &tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if name != tt.working {
+							return ctx.Machine.Error(name + " unavailable"), nil
+						}
+						return ctx.Machine.Done("from " + name), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, name)
+						return nil, nil
+					},
+				}
+			}
 
-	// Run the machine
-	for i := 0; i < b.N; i++ {
-		_, _ = m.Run()
-	}
-}
+			primary := source("primary")
+			secondary := source("secondary")
+			secondary.Fallback = source("cache")
+			primary.Fallbacks = []*tango.Step[Services, State]{secondary, source("tertiary")}
 
-func BenchmarkMachine_Compensate(b *testing.B) {
-	// Create a new machine
-	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
-		Log: false,
-	}, &tango.SequentialStrategy[Services, State]{})
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{*primary},
+				&tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
 
-	// Add some steps to the machine
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step1",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Next("Next"), nil
-		},
-		Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Done("Compensated"), nil
-		},
-	})
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step2",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Error("I will be compensated"), nil
-		},
-		Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Done("Done"), nil
-		},
-	})
+			response, err := m.Run()
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.expectErr && (response == nil || response.Result != tt.expectedResult || response.Fallback != tt.expectedFallback) {
+				t.Errorf("expected result %v from fallback %q, got %+v", tt.expectedResult, tt.expectedFallback, response)
+			}
 
-	// Run the machine
-	for i := 0; i < b.N; i++ {
-		_, _ = m.Run()
+			var stepNames []string
+			for _, step := range m.ExecutedSteps {
+				stepNames = append(stepNames, step.Name)
+			}
+			if !reflect.DeepEqual(stepNames, tt.expectedStepNames) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedStepNames, stepNames)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
 	}
 }
 
-func BenchmarkMachine_Reset(b *testing.B) {
-	// Create a new machine
-	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
-		Log: false,
-	}, &tango.SequentialStrategy[Services, State]{})
+type compensateProgressTestCase struct {
+	name             string
+	steps            int
+	expectedProgress [][2]int
+}
 
-	// Add some steps to the machine
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step1",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Next("Next"), nil
-		},
-	})
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "Step2",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Done("Done"), nil
+func TestMachine_OnCompensateProgress(t *testing.T) {
+	tests := []compensateProgressTestCase{
+		{
+			name:             "ThreeStepRollback",
+			steps:            3,
+			expectedProgress: [][2]int{{1, 3}, {2, 3}, {3, 3}},
 		},
-	})
+	}
 
-	// Run the machine
-	_, _ = m.Run()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var progress [][2]int
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+				OnCompensateProgress: func(compensated, total int) {
+					progress = append(progress, [2]int{compensated, total})
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
 
-	// Reset the machine
-	for i := 0; i < b.N; i++ {
-		m.Reset()
-	}
-}
+			for i := 1; i <= tt.steps; i++ {
+				last := i == tt.steps
+				m.AddStep(tango.Step[Services, State]{
+					Name: fmt.Sprintf("Step%d", i),
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if last {
+							return ctx.Machine.Error("I will be compensated"), nil
+						}
+						return ctx.Machine.Next("Next"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				})
+			}
 
-func BenchmarkMachine_100Steps_Run_Sequential(b *testing.B) {
-	// Create a new machine
-	m := tango.NewMachine(
-		"TestMachine",
-		[]tango.Step[Services, State]{},
-		&tango.MachineContext[Services, State]{},
-		&tango.MachineConfig[Services, State]{
-			Log: false,
-		},
-		&tango.SequentialStrategy[Services, State]{},
-	)
+			_, _ = m.Run()
 
-	// Add 100 steps to the machine
-	for i := 0; i < 100; i++ {
-		m.NewStep(&tango.Step[Services, State]{
-			Name: fmt.Sprintf("Step%d", i),
-			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-				return m.Next("Next"), nil
-			},
+			if !reflect.DeepEqual(progress, tt.expectedProgress) {
+				t.Errorf("expected compensation progress %v, got %v", tt.expectedProgress, progress)
+			}
 		})
 	}
+}
 
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "LastStep",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Done("Done"), nil
-		},
-	})
+type flagProvider map[string]bool
+
+func (f flagProvider) Enabled(name string, ctx *tango.MachineContext[Services, State]) bool {
+	return f[name]
+}
+
+type featureFlagTestCase struct {
+	name              string
+	flags             flagProvider
+	expectedStepNames []string
+}
+
+func TestMachine_Step_FeatureFlag(t *testing.T) {
+	tests := []featureFlagTestCase{
+		{
+			name:              "FlagEnabled",
+			flags:             flagProvider{"new-pricing": true},
+			expectedStepNames: []string{"Step1", "Step2", "Step3"},
+		},
+		{
+			name:              "FlagDisabled",
+			flags:             flagProvider{"new-pricing": false},
+			expectedStepNames: []string{"Step1", "Step3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name:        "Step2",
+					FeatureFlag: "new-pricing",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log:          false,
+				FlagProvider: tt.flags,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			var stepNames []string
+			for _, step := range m.ExecutedSteps {
+				stepNames = append(stepNames, step.Name)
+			}
+			if !reflect.DeepEqual(stepNames, tt.expectedStepNames) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedStepNames, stepNames)
+			}
+		})
+	}
+}
+
+type compensatePreviousResultTestCase struct {
+	name            string
+	expectedResults map[string]any
+}
+
+func TestMachine_Compensate_PreviousResult(t *testing.T) {
+	tests := []compensatePreviousResultTestCase{
+		{
+			name:            "CompensateSeesOwnResult",
+			expectedResults: map[string]any{"Step1": "order-42", "Step2": "payment declined"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[string]any)
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					seen[name] = ctx.PreviousResult.Result
+					return ctx.Machine.Done("Compensated"), nil
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("order-42"), nil
+					},
+					Compensate: compensate("Step1"),
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("payment declined"), nil
+					},
+					Compensate: compensate("Step2"),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, _ = m.Run()
+
+			if !reflect.DeepEqual(seen, tt.expectedResults) {
+				t.Errorf("expected compensate functions to see %v, got %v", tt.expectedResults, seen)
+			}
+			if m.Context.PreviousResult == nil || m.Context.PreviousResult.Result != "payment declined" {
+				t.Errorf("expected previous result to be restored after compensation, got %v", m.Context.PreviousResult)
+			}
+		})
+	}
+}
+
+type correlatedError struct {
+	correlationID string
+	err           error
+}
+
+func (e *correlatedError) Error() string {
+	return fmt.Sprintf("[%s] %v", e.correlationID, e.err)
+}
+
+func (e *correlatedError) Unwrap() error {
+	return e.err
+}
+
+type wrapErrorTestCase struct {
+	name          string
+	compensate    bool
+	expectedError string
+}
+
+func TestMachine_WrapError(t *testing.T) {
+	tests := []wrapErrorTestCase{
+		{
+			name:          "WrapStepFailure",
+			compensate:    true,
+			expectedError: "[req-7] step Step1 failed: boom",
+		},
+		{
+			name:          "WrapCompensationError",
+			compensate:    false,
+			expectedError: "[req-7] compensate error: step Step1 has no compensate function",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := tango.Step[Services, State]{
+				Name: "Step1",
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Error("boom"), nil
+				},
+			}
+			if tt.compensate {
+				step.Compensate = func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Done("Compensated"), nil
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{step}, &tango.MachineContext[Services, State]{
+				Services: Services{Database: "req-7"},
+			}, &tango.MachineConfig[Services, State]{
+				Log: false,
+				WrapError: func(err error, ctx *tango.MachineContext[Services, State]) error {
+					return &correlatedError{correlationID: ctx.Services.Database, err: err}
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, err := m.Run()
+
+			var correlated *correlatedError
+			if !errors.As(err, &correlated) || correlated.correlationID != "req-7" {
+				t.Errorf("expected error to carry the correlation ID, got %v", err)
+			}
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+type resetTestCase struct {
+	name              string
+	steps             []tango.Step[Services, State]
+	expectedSteps     int
+	expectedExecSteps int
+}
+
+func TestMachine_Reset(t *testing.T) {
+	tests := []resetTestCase{
+		{
+			name: "ResetAfterRun",
+			steps: []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			},
+			expectedSteps:     0,
+			expectedExecSteps: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &tango.MachineContext[Services, State]{}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+			context.Machine = m
+
+			for _, step := range tt.steps {
+				m.AddStep(step)
+			}
+
+			_, _ = m.Run()
+
+			m.Reset()
+
+			if len(m.Steps) != tt.expectedSteps {
+				t.Errorf("expected steps to be %v, got %v", tt.expectedSteps, len(m.Steps))
+			}
+			if len(m.ExecutedSteps) != tt.expectedExecSteps {
+				t.Errorf("expected executed steps to be %v, got %v", tt.expectedExecSteps, len(m.ExecutedSteps))
+			}
+		})
+	}
+}
+
+type autoUniqueNamesTestCase struct {
+	name          string
+	stepNames     []string
+	expectedNames []string
+}
+
+func TestMachine_AutoUniqueNames(t *testing.T) {
+	tests := []autoUniqueNamesTestCase{
+		{
+			name:          "ThreeDuplicates",
+			stepNames:     []string{"Step", "Step", "Step"},
+			expectedNames: []string{"Step", "Step-1", "Step-2"},
+		},
+		{
+			name:          "SuffixAlreadyTaken",
+			stepNames:     []string{"Step", "Step-1", "Step"},
+			expectedNames: []string{"Step", "Step-1", "Step-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log:             false,
+				AutoUniqueNames: true,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			var resolved []string
+			for _, name := range tt.stepNames {
+				resolved = append(resolved, m.AddStep(tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+				}))
+			}
+
+			if !reflect.DeepEqual(resolved, tt.expectedNames) {
+				t.Errorf("expected resolved names %v, got %v", tt.expectedNames, resolved)
+			}
+			for i, step := range m.Steps {
+				if step.Name != tt.expectedNames[i] {
+					t.Errorf("expected step %v, got %v", tt.expectedNames[i], step.Name)
+				}
+			}
+		})
+	}
+}
+
+type runFromTestCase struct {
+	name              string
+	entryStep         string
+	expectedError     string
+	expectedStepNames []string
+}
+
+func TestMachine_RunFrom(t *testing.T) {
+	tests := []runFromTestCase{
+		{
+			name:              "StartAtSecondStep",
+			entryStep:         "Step2",
+			expectedStepNames: []string{"Step2", "Step3"},
+		},
+		{
+			name:          "UnknownEntryStep",
+			entryStep:     "Step4",
+			expectedError: "entry step 'Step4' not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed []string
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			for i := 1; i <= 3; i++ {
+				name := fmt.Sprintf("Step%d", i)
+				m.AddStep(tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executed = append(executed, name)
+						if name == "Step3" {
+							return ctx.Machine.Done("Done"), nil
+						}
+						return ctx.Machine.Next("Next"), nil
+					},
+				})
+			}
+
+			_, err := m.RunFrom(tt.entryStep)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(executed, tt.expectedStepNames) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedStepNames, executed)
+			}
+		})
+	}
+}
+
+type stateTestCase struct {
+	name            string
+	initialState    State
+	steps           []tango.Step[Services, State]
+	expectedCounter int
+}
+
+func TestMachine_Context_State(t *testing.T) {
+	tests := []stateTestCase{
+		{
+			name:         "IncrementCounter",
+			initialState: State{Counter: 0},
+			steps: []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			},
+			expectedCounter: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &tango.MachineContext[Services, State]{State: tt.initialState}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+			context.Machine = m
+
+			for _, step := range tt.steps {
+				m.AddStep(step)
+			}
+
+			_, _ = m.Run()
+
+			if m.Context.State.Counter != tt.expectedCounter {
+				t.Errorf("expected state counter to be %v, got %v", tt.expectedCounter, m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type reduceTestCase struct {
+	name            string
+	results         []int
+	expectedCounter int
+}
+
+func TestMachine_Reduce(t *testing.T) {
+	tests := []reduceTestCase{
+		{
+			name:            "SumResults",
+			results:         []int{2, 3, 5},
+			expectedCounter: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+				Reduce: func(state State, response *tango.Response[Services, State]) State {
+					if n, ok := response.Result.(int); ok {
+						state.Counter += n
+					}
+					return state
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			for i, result := range tt.results {
+				last := i == len(tt.results)-1
+				m.AddStep(tango.Step[Services, State]{
+					Name: fmt.Sprintf("Step%d", i),
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if last {
+							return ctx.Machine.Done(result), nil
+						}
+						return ctx.Machine.Next(result), nil
+					},
+				})
+			}
+
+			if _, err := m.Run(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if m.Context.State.Counter != tt.expectedCounter {
+				t.Errorf("expected state counter to be %v, got %v", tt.expectedCounter, m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type servicesTestCase struct {
+	name             string
+	initialServices  Services
+	steps            []tango.Step[Services, State]
+	expectedDatabase string
+}
+
+func TestMachine_Context_Services(t *testing.T) {
+	tests := []servicesTestCase{
+		{
+			name:            "ChangeDatabaseService",
+			initialServices: Services{Database: "MySQL"},
+			steps: []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.Services.Database = "PostgreSQL"
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.Services.Database = "SQLite"
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			},
+			expectedDatabase: "SQLite",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &tango.MachineContext[Services, State]{Services: tt.initialServices}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+			context.Machine = m
+
+			for _, step := range tt.steps {
+				m.AddStep(step)
+			}
+
+			_, _ = m.Run()
+
+			if m.Context.Services.Database != tt.expectedDatabase {
+				t.Errorf("expected services database to be %v, got %v", tt.expectedDatabase, m.Context.Services.Database)
+			}
+		})
+	}
+}
+
+type jumpTestCase struct {
+	name              string
+	steps             []tango.Step[Services, State]
+	expectedResult    string
+	expectedError     error
+	expectedStepNames []string
+}
+
+func TestMachine_Step_Jump(t *testing.T) {
+	tests := []jumpTestCase{
+		{
+			name: "JumpToStep3",
+			steps: []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Jump("Jump", "Step3"), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("I got skipped"), nil
+					},
+				},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			},
+			expectedResult:    "Done",
+			expectedError:     nil,
+			expectedStepNames: []string{"Step1", "Step3"},
+		},
+		// Add more test cases as needed
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &tango.MachineContext[Services, State]{}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+			context.Machine = m
+
+			for _, step := range tt.steps {
+				m.AddStep(step)
+			}
+
+			response, err := m.Run()
+
+			if tt.expectedError != nil {
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if response == nil {
+				t.Errorf("expected response to be non-nil")
+			} else if response.Result != tt.expectedResult {
+				t.Errorf("expected result to be %v, got %v", tt.expectedResult, response.Result)
+			}
+
+			if len(m.ExecutedSteps) != len(tt.expectedStepNames) {
+				t.Errorf("expected %v executed steps, got %v", len(tt.expectedStepNames), len(m.ExecutedSteps))
+			}
+
+			for i, step := range m.ExecutedSteps {
+				if step.Name != tt.expectedStepNames[i] {
+					t.Errorf("expected step %v, got %v", tt.expectedStepNames[i], step.Name)
+				}
+			}
+		})
+	}
+}
+
+type missingJumpTestCase struct {
+	name                string
+	mode                tango.MissingJump
+	expectedError       string
+	expectedResult      any
+	expectedStepNames   []string
+	expectedCompensated []string
+}
+
+func TestMachine_OnMissingJump(t *testing.T) {
+	tests := []missingJumpTestCase{
+		{
+			name:              "Error",
+			mode:              tango.MissingJumpError,
+			expectedError:     "jump target 'Refund' not found at Route",
+			expectedStepNames: []string{"Reserve", "Route"},
+		},
+		{
+			name:              "Ignore",
+			mode:              tango.MissingJumpIgnore,
+			expectedResult:    "shipped",
+			expectedStepNames: []string{"Reserve", "Route", "Ship"},
+		},
+		{
+			name:                "Compensate",
+			mode:                tango.MissingJumpCompensate,
+			expectedError:       "jump target 'Refund' not found at Route",
+			expectedStepNames:   []string{"Reserve", "Route"},
+			expectedCompensated: []string{"Route", "Reserve"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compensated []string
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					return nil, nil
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Reserve",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("reserved"), nil
+					},
+					Compensate: compensate("Reserve"),
+				},
+				{
+					Name: "Route",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Jump("routed", "Refund"), nil
+					},
+					Compensate: compensate("Route"),
+				},
+				{
+					Name: "Ship",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("shipped"), nil
+					},
+					Compensate: compensate("Ship"),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				OnMissingJump: tt.mode,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if response == nil || response.Result != tt.expectedResult {
+				t.Errorf("expected result %v, got %+v", tt.expectedResult, response)
+			}
+
+			var stepNames []string
+			for _, step := range m.ExecutedSteps {
+				stepNames = append(stepNames, step.Name)
+			}
+			if !reflect.DeepEqual(stepNames, tt.expectedStepNames) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedStepNames, stepNames)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+type executionCountsTestCase struct {
+	name           string
+	iterations     int
+	expectedCounts map[string]int
+}
+
+func TestMachine_StepExecutionCounts(t *testing.T) {
+	tests := []executionCountsTestCase{
+		{
+			name:           "ThreeIterationLoop",
+			iterations:     3,
+			expectedCounts: map[string]int{"Step1": 3, "Step2": 3, "Step3": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if ctx.State.Counter < tt.iterations {
+							return ctx.Machine.Jump("Again", "Step1"), nil
+						}
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if counts := m.StepExecutionCounts(); !reflect.DeepEqual(counts, tt.expectedCounts) {
+				t.Errorf("expected execution counts %v, got %v", tt.expectedCounts, counts)
+			}
+		})
+	}
+}
+
+type stopConditionTestCase struct {
+	name            string
+	target          int
+	expectedCounter int
+	expectedResult  any
+}
+
+func TestMachine_StopCondition(t *testing.T) {
+	tests := []stopConditionTestCase{
+		{
+			name:            "LoopUntilConverged",
+			target:          5,
+			expectedCounter: 5,
+			expectedResult:  5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Increment",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return ctx.Machine.Jump(ctx.State.Counter, "Increment"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+				StopCondition: func(ctx *tango.MachineContext[Services, State]) bool {
+					return ctx.State.Counter >= tt.target
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response == nil || response.Status != tango.DONE || response.Result != tt.expectedResult {
+				t.Errorf("expected DONE with result %v, got %v", tt.expectedResult, response)
+			}
+			if m.Context.State.Counter != tt.expectedCounter {
+				t.Errorf("expected state counter to be %v, got %v", tt.expectedCounter, m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type doneIfTestCase struct {
+	name             string
+	cached           bool
+	expectedExecuted []string
+	expectedResult   any
+	expectedStatus   tango.ResponseStatus
+}
+
+func TestMachine_Step_DoneIf(t *testing.T) {
+	tests := []doneIfTestCase{
+		{
+			name:             "ShortCircuit",
+			cached:           true,
+			expectedExecuted: []string{"Lookup"},
+			expectedResult:   "cached",
+			expectedStatus:   tango.DONE,
+		},
+		{
+			name:             "Continue",
+			expectedExecuted: []string{"Lookup", "Fetch"},
+			expectedResult:   "fetched",
+			expectedStatus:   tango.DONE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed []string
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Lookup",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executed = append(executed, "Lookup")
+						if tt.cached {
+							return ctx.Machine.Next("cached"), nil
+						}
+						return ctx.Machine.Next(nil), nil
+					},
+					DoneIf: func(ctx *tango.MachineContext[Services, State]) bool {
+						return ctx.PreviousResult.Result != nil
+					},
+				},
+				{
+					Name: "Fetch",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executed = append(executed, "Fetch")
+						return ctx.Machine.Done("fetched"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response == nil || response.Status != tt.expectedStatus || response.Result != tt.expectedResult {
+				t.Errorf("expected %s with result %v, got %v", tt.expectedStatus, tt.expectedResult, response)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+		})
+	}
+}
+
+type stepSkipTestCase struct {
+	name                  string
+	steps                 []tango.Step[Services, State]
+	expectedExecutedSteps []string
+	expectedResult        string
+	expectedError         error
+}
+
+func TestMachine_Step_Skip(t *testing.T) {
+	tests := []stepSkipTestCase{
+		{
+			name: "SkipStep",
+			steps: []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Skip("Skip", 1), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("I will be skipped"), nil
+					},
+				},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			},
+			expectedExecutedSteps: []string{"Step1", "Step3"},
+			expectedResult:        "Done",
+			expectedError:         nil,
+		},
+		// Add more test cases as needed
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := &tango.MachineContext[Services, State]{}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, context, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+			context.Machine = m
+
+			for _, step := range tt.steps {
+				m.AddStep(step)
+			}
+
+			response, err := m.Run()
+
+			if err != tt.expectedError {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if response == nil {
+				t.Errorf("expected response to be non-nil, got nil")
+			} else if response.Result != tt.expectedResult {
+				t.Errorf("expected result to be %v, got %v", tt.expectedResult, response.Result)
+			}
+
+			executedStepNames := []string{}
+			for _, step := range m.ExecutedSteps {
+				executedStepNames = append(executedStepNames, step.Name)
+			}
+			if len(executedStepNames) != len(tt.expectedExecutedSteps) {
+				t.Errorf("expected executed steps to be %v, got %v", tt.expectedExecutedSteps, executedStepNames)
+			}
+		})
+	}
+}
+
+func BenchmarkMachine_Run_Sequential(b *testing.B) {
+	// Create a new machine
+	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+		Log: false,
+	}, &tango.SequentialStrategy[Services, State]{})
+
+	// Add some steps to the machine
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step1",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Next("Next"), nil
+		},
+	})
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step2",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Done("Done"), nil
+		},
+	})
+
+	// Run the machine
+	for i := 0; i < b.N; i++ {
+		_, _ = m.Run()
+	}
+}
+
+func BenchmarkMachine_Run_Concurrent(b *testing.B) {
+	// Create a new machine
+	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+		Log: false,
+	}, &tango.ConcurrentStrategy[Services, State]{})
+
+	// Add some steps to the machine
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step1",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Next("Next"), nil
+		},
+	})
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step2",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Done("Done"), nil
+		},
+	})
+
+	// Run the machine
+	for i := 0; i < b.N; i++ {
+		_, _ = m.Run()
+	}
+}
+
+func BenchmarkMachine_Compensate(b *testing.B) {
+	// Create a new machine
+	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+		Log: false,
+	}, &tango.SequentialStrategy[Services, State]{})
+
+	// Add some steps to the machine
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step1",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Next("Next"), nil
+		},
+		Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Done("Compensated"), nil
+		},
+	})
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step2",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Error("I will be compensated"), nil
+		},
+		Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Done("Done"), nil
+		},
+	})
+
+	// Run the machine
+	for i := 0; i < b.N; i++ {
+		_, _ = m.Run()
+	}
+}
+
+func BenchmarkMachine_Reset(b *testing.B) {
+	// Create a new machine
+	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+		Log: false,
+	}, &tango.SequentialStrategy[Services, State]{})
+
+	// Add some steps to the machine
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step1",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Next("Next"), nil
+		},
+	})
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "Step2",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Done("Done"), nil
+		},
+	})
+
+	// Run the machine
+	_, _ = m.Run()
+
+	// Reset the machine
+	for i := 0; i < b.N; i++ {
+		m.Reset()
+	}
+}
+
+func BenchmarkMachine_100Steps_Run_Sequential(b *testing.B) {
+	// Create a new machine
+	m := tango.NewMachine(
+		"TestMachine",
+		[]tango.Step[Services, State]{},
+		&tango.MachineContext[Services, State]{},
+		&tango.MachineConfig[Services, State]{
+			Log: false,
+		},
+		&tango.SequentialStrategy[Services, State]{},
+	)
+
+	// Add 100 steps to the machine
+	for i := 0; i < 100; i++ {
+		m.NewStep(&tango.Step[Services, State]{
+			Name: fmt.Sprintf("Step%d", i),
+			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return m.Next("Next"), nil
+			},
+		})
+	}
+
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "LastStep",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Done("Done"), nil
+		},
+	})
+
+	// Run the machine
+	for i := 0; i < b.N; i++ {
+		_, _ = m.Run()
+	}
+}
+
+func BenchmarkMachine_100Steps_Run_Concurrent(b *testing.B) {
+	// Create a new machine
+	m := tango.NewMachine(
+		"TestMachine",
+		[]tango.Step[Services, State]{},
+		&tango.MachineContext[Services, State]{},
+		&tango.MachineConfig[Services, State]{
+			Log: false,
+		},
+		&tango.ConcurrentStrategy[Services, State]{},
+	)
+
+	// Add 100 steps to the machine
+	for i := 0; i < 100; i++ {
+		m.NewStep(&tango.Step[Services, State]{
+			Name: fmt.Sprintf("Step%d", i),
+			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return m.Next("Next"), nil
+			},
+		})
+	}
+
+	m.NewStep(&tango.Step[Services, State]{
+		Name: "LastStep",
+		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+			return m.Done("Done"), nil
+		},
+	})
 
 	// Run the machine
 	for i := 0; i < b.N; i++ {
@@ -702,37 +2120,1104 @@ func BenchmarkMachine_100Steps_Run_Sequential(b *testing.B) {
 	}
 }
 
-func BenchmarkMachine_100Steps_Run_Concurrent(b *testing.B) {
-	// Create a new machine
-	m := tango.NewMachine(
-		"TestMachine",
-		[]tango.Step[Services, State]{},
-		&tango.MachineContext[Services, State]{},
-		&tango.MachineConfig[Services, State]{
-			Log: false,
+type nonCriticalTestCase struct {
+	name             string
+	failWithError    bool
+	expectedExecuted []string
+}
+
+func TestMachine_Step_NonCritical(t *testing.T) {
+	tests := []nonCriticalTestCase{
+		{
+			name:             "ErrorResponse",
+			expectedExecuted: []string{"Step1", "Notify", "Step3"},
+		},
+		{
+			name:             "ReturnedError",
+			failWithError:    true,
+			expectedExecuted: []string{"Step1", "Step3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compensated := false
+			compensate := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				compensated = true
+				return ctx.Machine.Done("Compensated"), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+					Compensate: compensate,
+				},
+				{
+					Name:        "Notify",
+					NonCritical: true,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if tt.failWithError {
+							return nil, errors.New("mail server down")
+						}
+						return ctx.Machine.Error("mail server down"), nil
+					},
+					Compensate: compensate,
+				},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+					Compensate: compensate,
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != "Done" {
+				t.Errorf("expected result 'Done', got %v", response.Result)
+			}
+			if compensated {
+				t.Errorf("expected no compensation")
+			}
+
+			var executed []string
+			for _, step := range m.ExecutedSteps {
+				executed = append(executed, step.Name)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+		})
+	}
+}
+
+type nonCriticalFallbackTestCase struct {
+	name                string
+	primaryNonCritical  bool
+	fallbackNonCritical bool
+	concurrent          bool
+	expectErr           bool
+}
+
+func TestMachine_Step_NonCriticalFallback(t *testing.T) {
+	tests := []nonCriticalFallbackTestCase{
+		{
+			name:               "NonCriticalStepCriticalFallback",
+			primaryNonCritical: true,
+		},
+		{
+			name:                "CriticalStepNonCriticalFallback",
+			fallbackNonCritical: true,
+			expectErr:           true,
+		},
+		{
+			name:               "ConcurrentNonCriticalStepCriticalFallback",
+			primaryNonCritical: true,
+			concurrent:         true,
+		},
+		{
+			name:                "ConcurrentCriticalStepNonCriticalFallback",
+			fallbackNonCritical: true,
+			concurrent:          true,
+			expectErr:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compensate := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return nil, nil
+			}
+			var strategy tango.ExecutionStrategy[Services, State] = &tango.SequentialStrategy[Services, State]{}
+			if tt.concurrent {
+				strategy = &tango.ConcurrentStrategy[Services, State]{Concurrency: 2}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name:        "Notify",
+					NonCritical: tt.primaryNonCritical,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("mail server down"), nil
+					},
+					Compensate: compensate,
+					Fallback: &tango.Step[Services, State]{
+						Name:        "NotifyBySMS",
+						NonCritical: tt.fallbackNonCritical,
+						Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+							return ctx.Machine.Error("sms gateway down"), nil
+						},
+						Compensate: compensate,
+					},
+				},
+				{
+					Name: "Finish",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Done"), nil
+					},
+					Compensate: compensate,
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, strategy)
+
+			_, err := m.Run()
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+type inheritServicesTestCase struct {
+	name             string
+	inheritServices  bool
+	expectedDatabase string
+}
+
+func TestMachine_InheritServices(t *testing.T) {
+	tests := []inheritServicesTestCase{
+		{
+			name:             "Inherit",
+			inheritServices:  true,
+			expectedDatabase: "MySQL",
+		},
+		{
+			name:             "Isolated",
+			expectedDatabase: "SQLite",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seenDatabase string
+
+			child := tango.NewMachine("Child", []tango.Step[Services, State]{
+				{
+					Name: "ChildStep",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						seenDatabase = ctx.Services.Database
+						ctx.State.Counter += 10
+						return ctx.Machine.Done("ChildDone"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{
+				Services: Services{Database: "SQLite"},
+			}, &tango.MachineConfig[Services, State]{
+				InheritServices: tt.inheritServices,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			m := tango.NewMachine("Parent", []tango.Step[Services, State]{
+				{
+					Name: "Spawn",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return tango.RunNewMachine[string, Services, State]("Spawned", child), nil
+					},
+				},
+				{
+					Name: "Finish",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(ctx.PreviousResult.Result), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{
+				Services: Services{Database: "MySQL"},
+			}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != "Spawned" {
+				t.Errorf("expected result 'Spawned', got %v", response.Result)
+			}
+			if seenDatabase != tt.expectedDatabase {
+				t.Errorf("expected the child to see database %q, got %q", tt.expectedDatabase, seenDatabase)
+			}
+			if child.Context.State.Counter != 10 {
+				t.Errorf("expected child counter 10, got %d", child.Context.State.Counter)
+			}
+			if m.Context.State.Counter != 1 {
+				t.Errorf("expected parent counter 1, got %d", m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type runIDTestCase struct {
+	name     string
+	newRunID func() string
+	expected []string
+}
+
+func TestMachine_RunID(t *testing.T) {
+	external := 0
+	tests := []runIDTestCase{
+		{
+			name: "Generated",
+		},
+		{
+			name: "External",
+			newRunID: func() string {
+				external++
+				return fmt.Sprintf("request-%d", external)
+			},
+			expected: []string{"request-1", "request-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []string
+			record := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				seen = append(seen, ctx.RunID)
+				return ctx.Machine.Next("Next"), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Step1", Execute: record},
+				{Name: "Step2", Execute: record},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				NewRunID: tt.newRunID,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			var runIDs []string
+			for i := 0; i < 2; i++ {
+				seen = nil
+				if _, err := m.Run(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if seen[0] == "" || seen[0] != seen[1] {
+					t.Errorf("expected a stable non-empty run ID within a run, got %v", seen)
+				}
+				runIDs = append(runIDs, seen[0])
+			}
+
+			if runIDs[0] == runIDs[1] {
+				t.Errorf("expected run IDs to change across runs, got %v", runIDs)
+			}
+			if tt.expected != nil && !reflect.DeepEqual(runIDs, tt.expected) {
+				t.Errorf("expected run IDs %v, got %v", tt.expected, runIDs)
+			}
+		})
+	}
+}
+
+type addStepDuringRunTestCase struct {
+	name             string
+	expectedExecuted []string
+}
+
+func TestMachine_AddStep_DuringRun(t *testing.T) {
+	tests := []addStepDuringRunTestCase{
+		{
+			name:             "ExternalRejectedInStepAllowed",
+			expectedExecuted: []string{"Step1", "Dynamic"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rejected interface{}
+			next := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+
+			var m *tango.Machine[Services, State]
+			m = tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						done := make(chan struct{})
+						go func() {
+							defer close(done)
+							defer func() { rejected = recover() }()
+							m.AddStep(tango.Step[Services, State]{Name: "External", Execute: next})
+						}()
+						<-done
+						ctx.AddStep(tango.Step[Services, State]{Name: "Dynamic", Execute: next})
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rejected == nil {
+				t.Errorf("expected AddStep from another goroutine during the run to panic")
+			}
+
+			var executed []string
+			for _, step := range m.ExecutedSteps {
+				executed = append(executed, step.Name)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+
+			m.AddStep(tango.Step[Services, State]{Name: "AfterRun", Execute: next})
+			if len(m.Steps) != 3 {
+				t.Errorf("expected AddStep to work once the run ended, got %d steps", len(m.Steps))
+			}
+		})
+	}
+}
+
+type addStepConcurrentTestCase struct {
+	name          string
+	expectedSteps int
+}
+
+func TestMachineContext_AddStep_Concurrent(t *testing.T) {
+	tests := []addStepConcurrentTestCase{
+		{
+			name:          "Rejected",
+			expectedSteps: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rejected atomic.Value
+			next := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						func() {
+							defer func() {
+								if r := recover(); r != nil {
+									rejected.Store(r)
+								}
+							}()
+							ctx.AddStep(tango.Step[Services, State]{Name: "Dynamic", Execute: next})
+						}()
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{Name: "Step2", Execute: next},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 2})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rejected.Load() == nil {
+				t.Error("expected MachineContext.AddStep to panic under ConcurrentStrategy")
+			}
+			if len(m.Steps) != tt.expectedSteps {
+				t.Errorf("expected %d steps, got %d", tt.expectedSteps, len(m.Steps))
+			}
+		})
+	}
+}
+
+type healthcheckTestCase struct {
+	name           string
+	machine        func() *tango.Machine[Services, State]
+	expectedErrors []string
+}
+
+func TestMachine_Healthcheck(t *testing.T) {
+	execute := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+		return ctx.Machine.Next(nil), nil
+	}
+	config := &tango.MachineConfig[Services, State]{}
+	strategy := &tango.SequentialStrategy[Services, State]{}
+
+	tests := []healthcheckTestCase{
+		{
+			name: "Healthy",
+			machine: func() *tango.Machine[Services, State] {
+				m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+					{Name: "Reserve", Execute: execute},
+					tango.BarrierStep[Services, State]("Barrier"),
+					{Name: "Charge", Execute: execute},
+				}, &tango.MachineContext[Services, State]{}, config, strategy)
+				m.JumpOnError("Charge", func(error) bool { return true })
+				return m
+			},
+		},
+		{
+			name: "NoSteps",
+			machine: func() *tango.Machine[Services, State] {
+				return tango.NewMachine("TestMachine", nil, &tango.MachineContext[Services, State]{}, config, strategy)
+			},
+			expectedErrors: []string{"machine TestMachine has no steps"},
+		},
+		{
+			name: "NoConfigOrStrategy",
+			machine: func() *tango.Machine[Services, State] {
+				return tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+					{Name: "Reserve", Execute: execute},
+				}, &tango.MachineContext[Services, State]{}, nil, nil)
+			},
+			expectedErrors: []string{"machine TestMachine has no config", "machine TestMachine has no strategy"},
+		},
+		{
+			name: "BrokenSteps",
+			machine: func() *tango.Machine[Services, State] {
+				m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+					{Name: "Reserve", Execute: execute},
+					{Name: "Reserve", Execute: execute},
+					{Name: "Charge", Fallback: &tango.Step[Services, State]{Name: "Invoice"}},
+				}, &tango.MachineContext[Services, State]{}, config, strategy)
+				m.JumpOnError("Refund", func(error) bool { return true })
+				return m
+			},
+			expectedErrors: []string{
+				"step name Reserve is used more than once",
+				"step Charge has no execute function",
+				"step Invoice has no execute function",
+				"jump target 'Refund' not found",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.machine().Healthcheck()
+			if tt.expectedErrors == nil {
+				if err != nil {
+					t.Errorf("expected a healthy machine, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %v, got none", tt.expectedErrors)
+			}
+			if messages := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(messages, tt.expectedErrors) {
+				t.Errorf("expected errors %v, got %v", tt.expectedErrors, messages)
+			}
+		})
+	}
+}
+
+type runWithTestCase struct {
+	name     string
+	override func(state *State)
+	expected int
+}
+
+func TestMachine_RunWith(t *testing.T) {
+	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+		{
+			Name: "Increment",
+			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				ctx.State.Counter++
+				return ctx.Machine.Done(ctx.State.Counter), nil
+			},
+		},
+	}, &tango.MachineContext[Services, State]{State: State{Counter: 1}}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+	tests := []runWithTestCase{
+		{name: "NoOverride", expected: 2},
+		{name: "Ten", override: func(state *State) { state.Counter = 10 }, expected: 11},
+		{name: "Hundred", override: func(state *State) { state.Counter = 100 }, expected: 101},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := m.RunWith(tt.override)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != tt.expected {
+				t.Errorf("expected result %d, got %v", tt.expected, response.Result)
+			}
+			if m.Context.State.Counter != 1 {
+				t.Errorf("expected the template state to stay at 1, got %d", m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type nestedCancelTestCase struct {
+	name        string
+	cancelAfter time.Duration
+}
+
+func TestMachine_RunNewMachine_Cancel(t *testing.T) {
+	tests := []nestedCancelTestCase{
+		{name: "CancelDuringChildStep", cancelAfter: 10 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var childCancelled, afterRan atomic.Bool
+
+			child := tango.NewMachine("Child", []tango.Step[Services, State]{
+				{
+					Name: "Wait",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						select {
+						case <-ctx.Context().Done():
+							childCancelled.Store(true)
+						case <-time.After(time.Second):
+						}
+						return ctx.Machine.Next(nil), nil
+					},
+				},
+				{
+					Name: "After",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						afterRan.Store(true)
+						return ctx.Machine.Done(nil), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			m := tango.NewMachine("Parent", []tango.Step[Services, State]{
+				{
+					Name: "Spawn",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return tango.RunNewMachine[string, Services, State]("Spawned", child), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			time.AfterFunc(tt.cancelAfter, cancel)
+
+			_, err := m.RunContext(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected the run to fail with context.Canceled, got %v", err)
+			}
+			if !childCancelled.Load() {
+				t.Error("expected the nested machine's step to observe the cancellation")
+			}
+			if afterRan.Load() {
+				t.Error("expected the nested machine to stop before its next step")
+			}
+		})
+	}
+}
+
+type currentStepTestCase struct {
+	name     string
+	strategy tango.ExecutionStrategy[Services, State]
+}
+
+func TestMachine_CurrentStep(t *testing.T) {
+	tests := []currentStepTestCase{
+		{name: "Sequential", strategy: &tango.SequentialStrategy[Services, State]{}},
+		{name: "Concurrent", strategy: &tango.ConcurrentStrategy[Services, State]{Concurrency: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			release := make(chan struct{})
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Slow",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						close(started)
+						<-release
+						return ctx.Machine.Next(nil), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, tt.strategy)
+
+			if _, _, ok := m.CurrentStep(); ok {
+				t.Error("expected no current step before the run")
+			}
+
+			before := time.Now()
+			runErr := make(chan error, 1)
+			go func() {
+				_, err := m.Run()
+				runErr <- err
+			}()
+			<-started
+
+			for i := 0; i < 10; i++ {
+				name, since, ok := m.CurrentStep()
+				if !ok || name != "Slow" {
+					t.Fatalf("expected current step Slow, got %q (ok %v)", name, ok)
+				}
+				if since.Before(before) {
+					t.Errorf("expected the step to start after %v, got %v", before, since)
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			close(release)
+			if err := <-runErr; err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, _, ok := m.CurrentStep(); ok {
+				t.Error("expected no current step after the run")
+			}
+		})
+	}
+}
+
+type progressEvent struct {
+	subscriber string
+	step       string
+	fraction   float64
+	message    string
+}
+
+type reportProgressTestCase struct {
+	name      string
+	fractions []float64
+	expected  []float64
+}
+
+func TestMachineContext_ReportProgress(t *testing.T) {
+	tests := []reportProgressTestCase{
+		{
+			name:      "Quarters",
+			fractions: []float64{0.25, 0.5, 1},
+			expected:  []float64{0.25, 0.5, 1},
 		},
-		&tango.ConcurrentStrategy[Services, State]{},
-	)
+		{
+			name:      "Clamped",
+			fractions: []float64{-0.5, 1.5},
+			expected:  []float64{0, 1},
+		},
+	}
 
-	// Add 100 steps to the machine
-	for i := 0; i < 100; i++ {
-		m.NewStep(&tango.Step[Services, State]{
-			Name: fmt.Sprintf("Step%d", i),
-			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-				return m.Next("Next"), nil
-			},
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []progressEvent
+			subscribe := func(subscriber string) func(ctx *tango.MachineContext[Services, State], step string, fraction float64, message string) {
+				return func(ctx *tango.MachineContext[Services, State], step string, fraction float64, message string) {
+					events = append(events, progressEvent{subscriber, step, fraction, message})
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				tango.NoOpStep[Services, State]("Prepare"),
+				{
+					Name: "Download",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						for _, fraction := range tt.fractions {
+							ctx.ReportProgress(fraction, "downloading")
+						}
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				OnProgress: subscribe("config"),
+				Plugins:    []tango.Plugin[Services, State]{{OnProgress: subscribe("plugin")}},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var expected []progressEvent
+			for _, fraction := range tt.expected {
+				expected = append(expected,
+					progressEvent{"config", "Download", fraction, "downloading"},
+					progressEvent{"plugin", "Download", fraction, "downloading"},
+				)
+			}
+			if !reflect.DeepEqual(events, expected) {
+				t.Errorf("expected progress %v, got %v", expected, events)
+			}
 		})
 	}
+}
 
-	m.NewStep(&tango.Step[Services, State]{
-		Name: "LastStep",
-		Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
-			return m.Done("Done"), nil
+type reportProgressConcurrentTestCase struct {
+	name  string
+	steps int
+}
+
+func TestMachineContext_ReportProgress_Concurrent(t *testing.T) {
+	tests := []reportProgressConcurrentTestCase{
+		{
+			name:  "EachStepReportsItsOwnName",
+			steps: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var events []progressEvent
+			steps := make([]tango.Step[Services, State], tt.steps)
+			for i := range steps {
+				name := fmt.Sprintf("Step%d", i)
+				steps[i] = tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						time.Sleep(time.Millisecond)
+						ctx.ReportProgress(1, name)
+						return ctx.Machine.Next(nil), nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				OnProgress: func(ctx *tango.MachineContext[Services, State], step string, fraction float64, message string) {
+					mu.Lock()
+					defer mu.Unlock()
+					events = append(events, progressEvent{"config", step, fraction, message})
+				},
+			}, &tango.ConcurrentStrategy[Services, State]{Concurrency: tt.steps})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(events) != tt.steps {
+				t.Fatalf("expected %d progress reports, got %d", tt.steps, len(events))
+			}
+			for _, event := range events {
+				if event.step != event.message {
+					t.Errorf("expected step %s to report its own name, got %s", event.message, event.step)
+				}
+			}
+		})
+	}
+}
+
+type autoDoneTestCase struct {
+	name           string
+	autoDone       bool
+	skipLast       bool
+	expectedStatus tango.ResponseStatus
+	expectedResult any
+}
+
+func TestMachine_AutoDoneOnLastStep(t *testing.T) {
+	tests := []autoDoneTestCase{
+		{
+			name:           "Enabled",
+			autoDone:       true,
+			expectedStatus: tango.DONE,
+			expectedResult: "total",
+		},
+		{
+			name: "Disabled",
+		},
+		{
+			name:     "LastStepSkipped",
+			autoDone: true,
+			skipLast: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if tt.skipLast {
+							return ctx.Machine.Skip("subtotal", 1), nil
+						}
+						return ctx.Machine.Next("subtotal"), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("total"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				AutoDoneOnLastStep: tt.autoDone,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedStatus == "" {
+				if response != nil {
+					t.Errorf("expected no response, got %+v", response)
+				}
+				return
+			}
+			if response == nil || response.Status != tt.expectedStatus || response.Result != tt.expectedResult {
+				t.Errorf("expected %s response with result %v, got %+v", tt.expectedStatus, tt.expectedResult, response)
+			}
+		})
+	}
+}
+
+type scraperServices struct {
+	client *http.Client
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type runWithServicesTestCase struct {
+	name           string
+	status         int
+	body           string
+	expectedResult any
+	expectErr      bool
+}
+
+func TestMachine_RunWithServices(t *testing.T) {
+	tests := []runWithServicesTestCase{
+		{
+			name:           "StubPage",
+			status:         http.StatusOK,
+			body:           "<html>stub</html>",
+			expectedResult: "<html>stub</html>",
+		},
+		{
+			name:      "StubError",
+			status:    http.StatusServiceUnavailable,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := scraperServices{client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				t.Error("expected the template's client not to be used")
+				return nil, errors.New("unexpected request")
+			})}}
+			stub := scraperServices{client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body)), Request: req}, nil
+			})}}
+
+			m := tango.NewMachine("Scraper", []tango.Step[scraperServices, State]{
+				{
+					Name: "visit website",
+					Execute: func(ctx *tango.MachineContext[scraperServices, State]) (*tango.Response[scraperServices, State], error) {
+						resp, err := ctx.Services.client.Get("https://example.com/")
+						if err != nil {
+							return ctx.Machine.Error(err), nil
+						}
+						defer resp.Body.Close()
+						if resp.StatusCode != http.StatusOK {
+							return ctx.Machine.Error(fmt.Sprintf("status code: %d", resp.StatusCode)), nil
+						}
+						content, err := io.ReadAll(resp.Body)
+						if err != nil {
+							return ctx.Machine.Error(err), nil
+						}
+						return ctx.Machine.Done(string(content)), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[scraperServices, State]) (*tango.Response[scraperServices, State], error) {
+						return nil, nil
+					},
+				},
+			}, &tango.MachineContext[scraperServices, State]{Services: template}, &tango.MachineConfig[scraperServices, State]{}, &tango.SequentialStrategy[scraperServices, State]{})
+
+			response, err := m.RunWithServices(stub)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.expectErr && (response == nil || response.Result != tt.expectedResult) {
+				t.Errorf("expected result %v, got %+v", tt.expectedResult, response)
+			}
+			if m.Context.Services.client != template.client || m.InitialContext.Services.client != template.client {
+				t.Error("expected the machine's services to be left untouched")
+			}
+		})
+	}
+}
+
+type viewTestCase struct {
+	name              string
+	expectedDuring    []string
+	expectedAfter     []string
+	expectedCounterAt int
+}
+
+func TestMachine_View(t *testing.T) {
+	tests := []viewTestCase{
+		{
+			name:              "TwoSteps",
+			expectedDuring:    []string{"Step1"},
+			expectedAfter:     []string{"Step1", "Step2"},
+			expectedCounterAt: 1,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var during *tango.View[Services, State]
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return ctx.Machine.Next("Next"), nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						during = ctx.Machine.View()
+						ctx.State.Counter++
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			if view := m.View(); view != nil {
+				t.Errorf("expected no view before the first run, got %+v", view)
+			}
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			names := func(view *tango.View[Services, State]) []string {
+				var names []string
+				for _, decision := range view.Decisions {
+					names = append(names, decision.Step)
+				}
+				return names
+			}
+			if !reflect.DeepEqual(names(during), tt.expectedDuring) {
+				t.Errorf("expected decisions %v during the run, got %v", tt.expectedDuring, names(during))
+			}
+			if during.State.Counter != tt.expectedCounterAt {
+				t.Errorf("expected counter %d during the run, got %d", tt.expectedCounterAt, during.State.Counter)
+			}
+			after := m.View()
+			if !reflect.DeepEqual(names(after), tt.expectedAfter) {
+				t.Errorf("expected decisions %v after the run, got %v", tt.expectedAfter, names(after))
+			}
+			if after.RunID != m.Context.RunID || after.PreviousResult.Result != "Done" {
+				t.Errorf("expected the view of run %s ending with Done, got %+v", m.Context.RunID, after)
+			}
+		})
+	}
+}
+
+func BenchmarkMachine_View(b *testing.B) {
+	steps := make([]tango.Step[Services, State], 0, 10)
+	for i := 0; i < 10; i++ {
+		steps = append(steps, tango.NoOpStep[Services, State](fmt.Sprintf("Step%d", i)))
+	}
+	m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{},
+		&tango.ConcurrentStrategy[Services, State]{Concurrency: 4})
+
+	// Keep the machine running so reads contend with execution.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_, _ = m.Run()
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	b.Run("Locked", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = m.Trace()
+			}
+		})
+	})
+	b.Run("Atomic", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = m.View()
+			}
+		})
 	})
+}
 
-	// Run the machine
-	for i := 0; i < b.N; i++ {
-		_, _ = m.Run()
+type countingRecorder struct {
+	mu            sync.Mutex
+	executions    map[string]int
+	compensations map[string]int
+}
+
+func (r *countingRecorder) StepExecuted(ctx context.Context, machine, step string, duration time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executions[step]++
+}
+
+func (r *countingRecorder) StepCompensated(ctx context.Context, machine, step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.compensations[step]++
+}
+
+type metricLabelTestCase struct {
+	name                  string
+	pages                 int
+	expectedExecutions    map[string]int
+	expectedCompensations map[string]int
+}
+
+func TestMachine_Step_MetricLabel(t *testing.T) {
+	tests := []metricLabelTestCase{
+		{
+			name:                  "GeneratedSteps",
+			pages:                 5,
+			expectedExecutions:    map[string]int{"fetch-page": 5, "Report": 1},
+			expectedCompensations: map[string]int{"fetch-page": 5, "Report": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compensate := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return nil, nil
+			}
+			var steps []tango.Step[Services, State]
+			for i := 1; i <= tt.pages; i++ {
+				steps = append(steps, tango.Step[Services, State]{
+					Name:        fmt.Sprintf("fetch-page-%d", i),
+					MetricLabel: "fetch-page",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(i), nil
+					},
+					Compensate: compensate,
+				})
+			}
+			steps = append(steps, tango.Step[Services, State]{
+				Name: "Report",
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Error("report unavailable"), nil
+				},
+				Compensate: compensate,
+			})
+
+			recorder := &countingRecorder{executions: map[string]int{}, compensations: map[string]int{}}
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Metrics: recorder,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected the run to fail")
+			}
+			if !reflect.DeepEqual(recorder.executions, tt.expectedExecutions) {
+				t.Errorf("expected executions %v, got %v", tt.expectedExecutions, recorder.executions)
+			}
+			if !reflect.DeepEqual(recorder.compensations, tt.expectedCompensations) {
+				t.Errorf("expected compensations %v, got %v", tt.expectedCompensations, recorder.compensations)
+			}
+		})
 	}
 }
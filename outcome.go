@@ -0,0 +1,173 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// StepDisposition is how a step ended up once a run is over.
+type StepDisposition string
+
+const (
+	StepSucceeded   StepDisposition = "SUCCEEDED"
+	StepFailed      StepDisposition = "FAILED"
+	StepCompensated StepDisposition = "COMPENSATED"
+	StepSkipped     StepDisposition = "SKIPPED"
+)
+
+// StepOutcome is the final disposition of one step of a run.
+type StepOutcome struct {
+	Step        string
+	Disposition StepDisposition
+}
+
+// RunOutcome is the result of a run together with the progress it made, so partial
+// results can be salvaged when the run fails or times out.
+type RunOutcome[Services, State any] struct {
+	Response       *Response[Services, State]
+	Err            error
+	PreviousResult *Response[Services, State]
+	CompletedSteps []string
+	// StepResponses holds the response of every step execution of the run, in order. A step
+	// that ran several times appears once per execution.
+	StepResponses []*Response[Services, State]
+	// Compensated reports whether the run rolled back its executed steps.
+	Compensated bool
+	// Warnings holds the warnings steps returned during the run, in order.
+	Warnings []Warning
+	// Allocs is the memory allocated during the run, when MachineConfig.TrackAllocs is set.
+	Allocs *AllocStats
+}
+
+// AllocStats is the memory allocated during a run, from the difference in the runtime's
+// allocator statistics before and after it. The statistics are process-wide, so allocations
+// made by other goroutines while the run was going are included.
+type AllocStats struct {
+	// Bytes is the number of bytes allocated for heap objects.
+	Bytes uint64
+	// Objects is the number of heap objects allocated.
+	Objects uint64
+}
+
+// trackAllocs reads the allocator statistics and returns a function that records the
+// allocations made since as the run's AllocStats.
+func (m *Machine[Services, State]) trackAllocs() func() {
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	return func() {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		m.mu.Lock()
+		m.allocs = &AllocStats{
+			Bytes:   after.TotalAlloc - before.TotalAlloc,
+			Objects: after.Mallocs - before.Mallocs,
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Warning is a non-fatal diagnostic a step returned with NextWithWarning.
+type Warning struct {
+	Step    string
+	Message string
+}
+
+// Warnings returns the warnings steps returned during the last run, in order.
+func (m *Machine[Services, State]) Warnings() []Warning {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Warning(nil), m.warnings...)
+}
+
+// StepOutcomes returns the final disposition of each step of the last run: an entry for every
+// step execution, in order, followed by the step the run failed at if its failure was not
+// recorded as a response, and then, with MachineConfig.TrackSkipped, the skipped steps. A step
+// that failed is reported as failed even if it was then compensated; other steps that were
+// rolled back are reported as compensated.
+func (m *Machine[Services, State]) StepOutcomes() []StepOutcome {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	outcomes := make([]StepOutcome, 0, len(m.decisions)+len(m.skipped)+1)
+	for _, decision := range m.decisions {
+		disposition := StepSucceeded
+		if decision.Status == ERROR {
+			disposition = StepFailed
+		}
+		outcomes = append(outcomes, StepOutcome{Step: decision.Step, Disposition: disposition})
+	}
+	if m.failedStep != "" {
+		last := len(m.decisions) - 1
+		if last < 0 || m.decisions[last].Step != m.failedStep || m.decisions[last].Status != ERROR {
+			outcomes = append(outcomes, StepOutcome{Step: m.failedStep, Disposition: StepFailed})
+		}
+	}
+	for _, step := range m.skipped {
+		outcomes = append(outcomes, StepOutcome{Step: step.Name, Disposition: StepSkipped})
+	}
+
+	// Compensation walks the history in reverse, so each compensation of a step belongs to its
+	// most recent execution not yet accounted for.
+	compensations := make(map[string]int, len(m.compensatedSteps))
+	for _, name := range m.compensatedSteps {
+		compensations[name]++
+	}
+	for i := len(outcomes) - 1; i >= 0; i-- {
+		outcome := &outcomes[i]
+		if compensations[outcome.Step] == 0 {
+			continue
+		}
+		compensations[outcome.Step]--
+		if outcome.Disposition != StepFailed {
+			outcome.Disposition = StepCompensated
+		}
+	}
+	return outcomes
+}
+
+// RunWithOutcome executes the machine steps like RunContext and reports the outcome.
+func (m *Machine[Services, State]) RunWithOutcome(ctx context.Context) RunOutcome[Services, State] {
+	response, err := m.RunContext(ctx)
+	return m.outcome(response, err)
+}
+
+// outcome builds the outcome of the last run.
+func (m *Machine[Services, State]) outcome(response *Response[Services, State], err error) RunOutcome[Services, State] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	completed := make([]string, 0, len(m.decisions))
+	for _, decision := range m.decisions {
+		completed = append(completed, decision.Step)
+	}
+	responses := make([]*Response[Services, State], len(m.runResponses))
+	copy(responses, m.runResponses)
+	return RunOutcome[Services, State]{
+		Response:       response,
+		Err:            err,
+		PreviousResult: m.Context.PreviousResult,
+		CompletedSteps: completed,
+		StepResponses:  responses,
+		Compensated:    m.compensated,
+		Warnings:       append([]Warning(nil), m.warnings...),
+		Allocs:         m.allocs,
+	}
+}
+
+// RunTyped runs the machine and returns the result of its final response as an R. It fails
+// if the run fails, ends without a response, or the result is not an R.
+func RunTyped[R, Services, State any](m *Machine[Services, State]) (R, error) {
+	var zero R
+	response, err := m.Run()
+	if err != nil {
+		return zero, err
+	}
+	if response == nil {
+		return zero, fmt.Errorf("machine %s finished without a response", m.Name)
+	}
+	result, ok := response.Result.(R)
+	if !ok {
+		return zero, fmt.Errorf("machine %s result is %T, not %T", m.Name, response.Result, zero)
+	}
+	return result, nil
+}
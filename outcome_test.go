@@ -0,0 +1,489 @@
+package tango_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phr3nzy/tango"
+)
+
+type partialOutcomeTestCase struct {
+	name                   string
+	timeout                time.Duration
+	expectedCompletedSteps []string
+	expectedPrevious       string
+}
+
+func TestMachine_RunWithOutcome_Timeout(t *testing.T) {
+	tests := []partialOutcomeTestCase{
+		{
+			name:                   "TimeoutAfterTwoSteps",
+			timeout:                20 * time.Millisecond,
+			expectedCompletedSteps: []string{"Step1", "Step2"},
+			expectedPrevious:       "page 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetch := func(page string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Next(page), nil
+				}
+			}
+			hang := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				<-ctx.Context().Done()
+				return nil, ctx.Context().Err()
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Step1", Execute: fetch("page 1")},
+				{Name: "Step2", Execute: fetch("page 2")},
+				{Name: "Step3", Execute: hang},
+				{Name: "Step4", Execute: fetch("page 4")},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			outcome := m.RunWithOutcome(ctx)
+
+			if !errors.Is(outcome.Err, context.DeadlineExceeded) {
+				t.Errorf("expected a deadline error, got %v", outcome.Err)
+			}
+			if outcome.Response != nil {
+				t.Errorf("expected no final response, got %v", outcome.Response)
+			}
+			if !reflect.DeepEqual(outcome.CompletedSteps, tt.expectedCompletedSteps) {
+				t.Errorf("expected completed steps %v, got %v", tt.expectedCompletedSteps, outcome.CompletedSteps)
+			}
+			if outcome.PreviousResult == nil || outcome.PreviousResult.Result != tt.expectedPrevious {
+				t.Errorf("expected previous result %v, got %v", tt.expectedPrevious, outcome.PreviousResult)
+			}
+		})
+	}
+}
+
+type receipt struct {
+	ID     string
+	Amount int
+}
+
+type runTypedTestCase struct {
+	name            string
+	result          interface{}
+	expectedReceipt receipt
+	expectedError   string
+}
+
+func TestRunTyped(t *testing.T) {
+	tests := []runTypedTestCase{
+		{
+			name:            "TypedResult",
+			result:          receipt{ID: "r-1", Amount: 42},
+			expectedReceipt: receipt{ID: "r-1", Amount: 42},
+		},
+		{
+			name:          "Mismatch",
+			result:        "r-1",
+			expectedError: "machine TestMachine result is string, not tango_test.receipt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Charge",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(tt.result), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			result, err := tango.RunTyped[receipt](m)
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expectedReceipt {
+				t.Errorf("expected receipt %+v, got %+v", tt.expectedReceipt, result)
+			}
+		})
+	}
+}
+
+type afterRunTestCase struct {
+	name                string
+	fail                bool
+	panic               bool
+	expectedCompleted   []string
+	expectedCompensated bool
+}
+
+func TestMachine_AfterRun(t *testing.T) {
+	tests := []afterRunTestCase{
+		{
+			name:              "Success",
+			expectedCompleted: []string{"Step1", "Step2"},
+		},
+		{
+			name:                "Failure",
+			fail:                true,
+			expectedCompleted:   []string{"Step1", "Step2"},
+			expectedCompensated: true,
+		},
+		{
+			name:              "Panic",
+			panic:             true,
+			expectedCompleted: []string{"Step1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outcomes []tango.RunOutcome[Services, State]
+			var order []string
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return nil, nil
+					},
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if tt.panic {
+							panic("boom")
+						}
+						if tt.fail {
+							return ctx.Machine.Error("failed"), nil
+						}
+						return ctx.Machine.Done("Done"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return nil, nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Plugins: []tango.Plugin[Services, State]{
+					{
+						Cleanup: func(ctx *tango.MachineContext[Services, State]) error {
+							order = append(order, "cleanup")
+							return nil
+						},
+					},
+				},
+				AfterRun: func(outcome tango.RunOutcome[Services, State]) {
+					order = append(order, "afterRun")
+					outcomes = append(outcomes, outcome)
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			var response *tango.Response[Services, State]
+			var err error
+			var recovered any
+			func() {
+				defer func() { recovered = recover() }()
+				response, err = m.Run()
+			}()
+
+			if tt.panic && recovered != "boom" {
+				t.Errorf("expected the run to panic again with boom, got %v", recovered)
+			}
+			if len(outcomes) != 1 {
+				t.Fatalf("expected AfterRun to be called once, got %d", len(outcomes))
+			}
+			outcome := outcomes[0]
+			if tt.panic {
+				if outcome.Response != nil || outcome.Err == nil || !strings.Contains(outcome.Err.Error(), "boom") {
+					t.Errorf("expected the outcome to report the panic, got %+v", outcome)
+				}
+			} else if outcome.Response != response || !errors.Is(outcome.Err, err) {
+				t.Errorf("expected the outcome to carry the run's response and error, got %+v", outcome)
+			}
+			if (outcome.Err != nil) != (tt.fail || tt.panic) {
+				t.Errorf("expected outcome error only on failure, got %v", outcome.Err)
+			}
+			if outcome.Compensated != tt.expectedCompensated {
+				t.Errorf("expected compensated %v, got %v", tt.expectedCompensated, outcome.Compensated)
+			}
+			if !reflect.DeepEqual(outcome.CompletedSteps, tt.expectedCompleted) {
+				t.Errorf("expected completed steps %v, got %v", tt.expectedCompleted, outcome.CompletedSteps)
+			}
+			if expected := []string{"cleanup", "afterRun"}; !reflect.DeepEqual(order, expected) {
+				t.Errorf("expected order %v, got %v", expected, order)
+			}
+		})
+	}
+}
+
+type stepResponsesTestCase struct {
+	name            string
+	loops           int
+	expectedResults []interface{}
+}
+
+func TestMachine_RunWithOutcome_StepResponses(t *testing.T) {
+	tests := []stepResponsesTestCase{
+		{
+			name:            "Loop",
+			loops:           2,
+			expectedResults: []interface{}{1, "checked", 2, "checked", 3, "done"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Increment",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						return ctx.Machine.Next(ctx.State.Counter), nil
+					},
+				},
+				{
+					Name: "Check",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if ctx.State.Counter <= tt.loops {
+							return ctx.Machine.Jump("checked", "Increment"), nil
+						}
+						return ctx.Machine.Done("done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			outcome := m.RunWithOutcome(context.Background())
+			if outcome.Err != nil {
+				t.Fatalf("unexpected error: %v", outcome.Err)
+			}
+
+			executions := 0
+			for _, count := range m.StepExecutionCounts() {
+				executions += count
+			}
+			if len(outcome.StepResponses) != executions {
+				t.Errorf("expected %d step responses, got %d", executions, len(outcome.StepResponses))
+			}
+
+			var results []interface{}
+			for _, response := range outcome.StepResponses {
+				results = append(results, response.Result)
+			}
+			if !reflect.DeepEqual(results, tt.expectedResults) {
+				t.Errorf("expected results %v, got %v", tt.expectedResults, results)
+			}
+		})
+	}
+}
+
+type warningsTestCase struct {
+	name             string
+	warnings         map[string]string
+	expectedWarnings []tango.Warning
+}
+
+func TestMachine_RunWithOutcome_Warnings(t *testing.T) {
+	tests := []warningsTestCase{
+		{
+			name:     "TwoWarnings",
+			warnings: map[string]string{"Reserve": "stock is low", "Ship": "carrier delayed"},
+			expectedWarnings: []tango.Warning{
+				{Step: "Reserve", Message: "stock is low"},
+				{Step: "Ship", Message: "carrier delayed"},
+			},
+		},
+		{
+			name: "NoWarnings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var steps []tango.Step[Services, State]
+			for _, name := range []string{"Reserve", "Charge", "Ship"} {
+				steps = append(steps, tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if warning, ok := tt.warnings[name]; ok {
+							return ctx.Machine.NextWithWarning(name, warning), nil
+						}
+						return ctx.Machine.Next(name), nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+			outcome := m.RunWithOutcome(context.Background())
+			if outcome.Err != nil {
+				t.Fatalf("unexpected error: %v", outcome.Err)
+			}
+			if len(outcome.CompletedSteps) != 3 {
+				t.Errorf("expected 3 completed steps, got %v", outcome.CompletedSteps)
+			}
+			if !reflect.DeepEqual(outcome.Warnings, tt.expectedWarnings) {
+				t.Errorf("expected warnings %v, got %v", tt.expectedWarnings, outcome.Warnings)
+			}
+			if !reflect.DeepEqual(m.Warnings(), tt.expectedWarnings) {
+				t.Errorf("expected machine warnings %v, got %v", tt.expectedWarnings, m.Warnings())
+			}
+		})
+	}
+}
+
+type allocsTestCase struct {
+	name        string
+	trackAllocs bool
+}
+
+var allocSink []byte
+
+func TestMachine_RunWithOutcome_Allocs(t *testing.T) {
+	tests := []allocsTestCase{
+		{name: "Tracked", trackAllocs: true},
+		{name: "NotTracked"},
+	}
+
+	const size = 1 << 20
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Buffer",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						allocSink = make([]byte, size)
+						return ctx.Machine.Done(len(allocSink)), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				TrackAllocs: tt.trackAllocs,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			outcome := m.RunWithOutcome(context.Background())
+			if outcome.Err != nil {
+				t.Fatalf("unexpected error: %v", outcome.Err)
+			}
+			if !tt.trackAllocs {
+				if outcome.Allocs != nil {
+					t.Errorf("expected no alloc stats, got %+v", outcome.Allocs)
+				}
+				return
+			}
+			if outcome.Allocs == nil {
+				t.Fatal("expected alloc stats")
+			}
+			if outcome.Allocs.Bytes < size || outcome.Allocs.Objects == 0 {
+				t.Errorf("expected at least %d bytes in at least one object, got %+v", size, outcome.Allocs)
+			}
+		})
+	}
+}
+
+type stepOutcomesTestCase struct {
+	name     string
+	reserve  tango.ResponseStatus
+	shipErr  bool
+	expected []tango.StepOutcome
+}
+
+func TestMachine_StepOutcomes(t *testing.T) {
+	tests := []stepOutcomesTestCase{
+		{
+			name:    "PartialFailure",
+			reserve: tango.NEXT,
+			expected: []tango.StepOutcome{
+				{Step: "Reserve", Disposition: tango.StepCompensated},
+				{Step: "Charge", Disposition: tango.StepCompensated},
+				{Step: "Ship", Disposition: tango.StepFailed},
+			},
+		},
+		{
+			name:    "Savepoint",
+			reserve: tango.SAVEPOINT,
+			expected: []tango.StepOutcome{
+				{Step: "Reserve", Disposition: tango.StepSucceeded},
+				{Step: "Charge", Disposition: tango.StepCompensated},
+				{Step: "Ship", Disposition: tango.StepFailed},
+			},
+		},
+		{
+			name:    "Skipped",
+			reserve: tango.SKIP,
+			expected: []tango.StepOutcome{
+				{Step: "Reserve", Disposition: tango.StepCompensated},
+				{Step: "Ship", Disposition: tango.StepFailed},
+				{Step: "Charge", Disposition: tango.StepSkipped},
+			},
+		},
+		{
+			name:    "ExecuteError",
+			reserve: tango.NEXT,
+			shipErr: true,
+			expected: []tango.StepOutcome{
+				{Step: "Reserve", Disposition: tango.StepSucceeded},
+				{Step: "Charge", Disposition: tango.StepSucceeded},
+				{Step: "Ship", Disposition: tango.StepFailed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compensate := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return nil, nil
+			}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Reserve",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return &tango.Response[Services, State]{Status: tt.reserve, SkipCount: 1}, nil
+					},
+					Compensate: compensate,
+				},
+				{
+					Name: "Charge",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("charged"), nil
+					},
+					Compensate: compensate,
+				},
+				{
+					Name: "Ship",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if tt.shipErr {
+							return nil, errors.New("carrier unavailable")
+						}
+						return ctx.Machine.Error("carrier unavailable"), nil
+					},
+					Compensate: compensate,
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				TrackSkipped: true,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected the run to fail")
+			}
+			if outcomes := m.StepOutcomes(); !reflect.DeepEqual(outcomes, tt.expected) {
+				t.Errorf("expected outcomes %v, got %v", tt.expected, outcomes)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+)
+
+// Stage is a typed step that turns an In into an Out.
+type Stage[In, Out, Services, State any] struct {
+	Name string
+	Run  func(ctx *MachineContext[Services, State], in In) (Out, error)
+}
+
+// step returns the untyped step that runs the stage. It reads its input from the previous
+// result and returns its output as the result of a NEXT response.
+func (s Stage[In, Out, Services, State]) step() Step[Services, State] {
+	return Step[Services, State]{
+		Name: s.Name,
+		Execute: func(ctx *MachineContext[Services, State]) (*Response[Services, State], error) {
+			var in In
+			if ctx.PreviousResult != nil && ctx.PreviousResult.Result != nil {
+				typed, ok := ctx.PreviousResult.Result.(In)
+				if !ok {
+					return nil, fmt.Errorf("stage %s expects input of type %T, got %T", s.Name, in, ctx.PreviousResult.Result)
+				}
+				in = typed
+			}
+			out, err := s.Run(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return Next[Out, Services, State](out), nil
+		},
+	}
+}
+
+// Pipeline is a chain of stages turning an In into an Out. It is built with Pipe and Then,
+// which only accept a stage whose input type is the output type of the stage before it:
+//
+//	parse := tango.Stage[string, int, Services, State]{...}
+//	double := tango.Stage[int, int, Services, State]{...}
+//	format := tango.Stage[int, string, Services, State]{...}
+//
+//	tango.Then(tango.Pipe(parse, double), format) // Pipeline[string, string, ...]
+//	tango.Pipe(parse, format)                     // compiles: int into int
+//	tango.Pipe(format, double)                    // does not compile: string is not int
+type Pipeline[In, Out, Services, State any] struct {
+	steps []Step[Services, State]
+}
+
+// Pipe chains two stages into a pipeline.
+func Pipe[A, B, C, Services, State any](first Stage[A, B, Services, State], second Stage[B, C, Services, State]) Pipeline[A, C, Services, State] {
+	return Pipeline[A, C, Services, State]{steps: []Step[Services, State]{first.step(), second.step()}}
+}
+
+// Then appends a stage to a pipeline.
+func Then[A, B, C, Services, State any](pipeline Pipeline[A, B, Services, State], next Stage[B, C, Services, State]) Pipeline[A, C, Services, State] {
+	steps := make([]Step[Services, State], 0, len(pipeline.steps)+1)
+	steps = append(steps, pipeline.steps...)
+	return Pipeline[A, C, Services, State]{steps: append(steps, next.step())}
+}
+
+// Steps returns the untyped steps the pipeline's stages run as, in order.
+func (p Pipeline[In, Out, Services, State]) Steps() []Step[Services, State] {
+	steps := make([]Step[Services, State], len(p.steps))
+	copy(steps, p.steps)
+	return steps
+}
+
+// Machine returns a sequential machine that runs the pipeline's stages. The first stage reads
+// its input from the context's previous result.
+func (p Pipeline[In, Out, Services, State]) Machine(name string, ctx *MachineContext[Services, State], config *MachineConfig[Services, State]) *Machine[Services, State] {
+	return NewMachine(name, p.Steps(), ctx, config, &SequentialStrategy[Services, State]{})
+}
+
+// Run runs the pipeline on input and returns the last stage's output. The machine runs
+// against a copy of machineCtx whose previous result holds input.
+func (p Pipeline[In, Out, Services, State]) Run(ctx context.Context, name string, machineCtx *MachineContext[Services, State], config *MachineConfig[Services, State], input In) (Out, error) {
+	var out Out
+	runContext := *machineCtx
+	runContext.PreviousResult = Next[In, Services, State](input)
+	m := p.Machine(name, &runContext, config)
+	if _, err := m.RunContext(ctx); err != nil {
+		return out, err
+	}
+	result := m.Context.PreviousResult.Result
+	if result == nil {
+		return out, nil
+	}
+	out, ok := result.(Out)
+	if !ok {
+		return out, fmt.Errorf("pipeline %s result is %T, not %T", name, result, out)
+	}
+	return out, nil
+}
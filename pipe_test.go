@@ -0,0 +1,141 @@
+package tango_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type pipeTestCase struct {
+	name          string
+	input         string
+	expected      int
+	expectedError string
+}
+
+func TestPipe(t *testing.T) {
+	parse := tango.Stage[string, int, Services, State]{
+		Name: "Parse",
+		Run: func(ctx *tango.MachineContext[Services, State], in string) (int, error) {
+			return strconv.Atoi(in)
+		},
+	}
+	double := tango.Stage[int, int, Services, State]{
+		Name: "Double",
+		Run: func(ctx *tango.MachineContext[Services, State], in int) (int, error) {
+			return in * 2, nil
+		},
+	}
+
+	tests := []pipeTestCase{
+		{name: "Number", input: "21", expected: 42},
+		{name: "NotANumber", input: "forty-two", expectedError: `strconv.Atoi: parsing "forty-two": invalid syntax`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := tango.Pipe(parse, double)
+			result, err := pipeline.Run(context.Background(), "TestPipe", &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, tt.input)
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
+type pipeResultTestCase struct {
+	name          string
+	trailing      any
+	expected      int
+	expectedError string
+}
+
+func TestPipeline_Run_Result(t *testing.T) {
+	double := tango.Stage[int, int, Services, State]{
+		Name: "Double",
+		Run: func(ctx *tango.MachineContext[Services, State], in int) (int, error) {
+			return in * 2, nil
+		},
+	}
+
+	tests := []pipeResultTestCase{
+		{name: "LastStageOutput", expected: 84},
+		{name: "UnexpectedType", trailing: "audited", expectedError: "pipeline TestPipe result is string, not int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &tango.MachineConfig[Services, State]{}
+			if tt.trailing != nil {
+				config.Plugins = []tango.Plugin[Services, State]{{
+					ContributeSteps: func(m *tango.Machine[Services, State]) []tango.Step[Services, State] {
+						return []tango.Step[Services, State]{{
+							Name: "Audit",
+							Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+								return ctx.Machine.Next(tt.trailing), nil
+							},
+						}}
+					},
+				}}
+			}
+
+			result, err := tango.Pipe(double, double).Run(context.Background(), "TestPipe", &tango.MachineContext[Services, State]{}, config, 21)
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestThen(t *testing.T) {
+	parse := tango.Stage[string, int, Services, State]{
+		Name: "Parse",
+		Run: func(ctx *tango.MachineContext[Services, State], in string) (int, error) {
+			return strconv.Atoi(in)
+		},
+	}
+	square := tango.Stage[int, int, Services, State]{
+		Name: "Square",
+		Run: func(ctx *tango.MachineContext[Services, State], in int) (int, error) {
+			return in * in, nil
+		},
+	}
+	format := tango.Stage[int, string, Services, State]{
+		Name: "Format",
+		Run: func(ctx *tango.MachineContext[Services, State], in int) (string, error) {
+			return "result: " + strconv.Itoa(in), nil
+		},
+	}
+
+	pipeline := tango.Then(tango.Pipe(parse, square), format)
+	result, err := pipeline.Run(context.Background(), "TestPipe", &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, "7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "result: 49" {
+		t.Errorf("expected 'result: 49', got %q", result)
+	}
+	if steps := pipeline.Steps(); len(steps) != 3 || steps[2].Name != "Format" {
+		t.Errorf("expected the stages Parse, Square and Format, got %d steps", len(steps))
+	}
+}
@@ -1,8 +1,75 @@
 package tango
 
+import (
+	"fmt"
+	"sort"
+)
+
+// Plugin is a struct that represents a machine plugin. Plugins run in ascending Priority
+// order (keeping their configured order on ties) for Init, ModifyExecutionStrategy and
+// Execute, and in the reverse order for Cleanup so teardown mirrors setup. Cleanup runs
+// once the run ends, whether it succeeds, fails or panics, and after any compensation; it
+// runs for every plugin whose Init succeeded. Name identifies the plugin in Machine.Describe.
 type Plugin[Services, State any] struct {
+	Name                    string
 	Init                    func(ctx *MachineContext[Services, State]) error
 	Execute                 func(ctx *MachineContext[Services, State]) error
 	Cleanup                 func(ctx *MachineContext[Services, State]) error
 	ModifyExecutionStrategy func(m *Machine[Services, State]) ExecutionStrategy[Services, State]
+	Priority                int
+	// ContributeSteps, when set, returns steps the plugin adds to each run, placed according
+	// to StepPosition. Steps from several plugins keep the plugins' run order. The machine's
+	// Steps are left as configured once the run ends.
+	ContributeSteps func(m *Machine[Services, State]) []Step[Services, State]
+	StepPosition    StepPosition
+	// OnResult, when set, is called with each step's response after MachineConfig.OnResult,
+	// under the same conditions.
+	OnResult func(ctx *MachineContext[Services, State], step string, response *Response[Services, State])
+	// OnProgress, when set, is called with the progress steps report, after
+	// MachineConfig.OnProgress.
+	OnProgress func(ctx *MachineContext[Services, State], step string, fraction float64, message string)
+	// Halt, when set, is asked after each step of a sequential run, once the step's response is
+	// recorded, whether the run should stop there. When it returns true the run finishes with a
+	// DONE response carrying the step's result, as a normal run would. A step that ends the run
+	// itself, by returning DONE, ERROR or SUSPEND, keeps its own outcome; Halt only stops a run
+	// that would otherwise go on. MachineConfig.StopCondition is evaluated first.
+	Halt func(ctx *MachineContext[Services, State], step string, response *Response[Services, State]) bool
+}
+
+// StepPosition says where the steps a plugin contributes are placed.
+type StepPosition int
+
+const (
+	// AppendSteps places contributed steps after the machine's steps.
+	AppendSteps StepPosition = iota
+	// PrependSteps places contributed steps before the machine's steps. A run started with
+	// RunFrom or Resume begins after them.
+	PrependSteps
+)
+
+// sortPlugins returns a copy of the plugins ordered by priority.
+func sortPlugins[Services, State any](plugins []Plugin[Services, State]) []Plugin[Services, State] {
+	sorted := make([]Plugin[Services, State], len(plugins))
+	copy(sorted, plugins)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return sorted
+}
+
+// halted reports whether a plugin halts the run after the step returned response.
+func (m *Machine[Services, State]) halted(step Step[Services, State], response *Response[Services, State]) bool {
+	switch response.Status {
+	case DONE, ERROR, SUSPEND:
+		return false
+	}
+	for _, plugin := range m.plugins {
+		if plugin.Halt != nil && plugin.Halt(m.Context, step.Name, response) {
+			if m.Config.Log {
+				fmt.Printf("[%s] plugin %s halted the run after step: %s\n", m.Context.RunID, plugin.Name, step.Name)
+			}
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,396 @@
+package tango_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type pluginPriorityTestCase struct {
+	name            string
+	priorities      map[string]int
+	order           []string
+	expectedInit    []string
+	expectedCleanup []string
+}
+
+func TestPlugin_Priority(t *testing.T) {
+	tests := []pluginPriorityTestCase{
+		{
+			name:            "MixedPriorities",
+			priorities:      map[string]int{"metrics": 10, "tracing": -5, "audit": 0, "logging": 0},
+			order:           []string{"metrics", "audit", "tracing", "logging"},
+			expectedInit:    []string{"tracing", "audit", "logging", "metrics"},
+			expectedCleanup: []string{"metrics", "logging", "audit", "tracing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var initOrder, cleanupOrder []string
+			var plugins []tango.Plugin[Services, State]
+			for _, name := range tt.order {
+				plugins = append(plugins, tango.Plugin[Services, State]{
+					Priority: tt.priorities[name],
+					Init: func(ctx *tango.MachineContext[Services, State]) error {
+						initOrder = append(initOrder, name)
+						return nil
+					},
+					Cleanup: func(ctx *tango.MachineContext[Services, State]) error {
+						cleanupOrder = append(cleanupOrder, name)
+						return nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log:     false,
+				Plugins: plugins,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(initOrder, tt.expectedInit) {
+				t.Errorf("expected init order %v, got %v", tt.expectedInit, initOrder)
+			}
+			if !reflect.DeepEqual(cleanupOrder, tt.expectedCleanup) {
+				t.Errorf("expected cleanup order %v, got %v", tt.expectedCleanup, cleanupOrder)
+			}
+		})
+	}
+}
+
+type pluginCleanupTestCase struct {
+	name          string
+	fail          bool
+	panic         bool
+	expectedOrder []string
+}
+
+func TestPlugin_Cleanup(t *testing.T) {
+	tests := []pluginCleanupTestCase{
+		{
+			name:          "Success",
+			expectedOrder: []string{"cleanup"},
+		},
+		{
+			name:          "StepError",
+			fail:          true,
+			expectedOrder: []string{"compensate", "cleanup"},
+		},
+		{
+			name:          "Panic",
+			panic:         true,
+			expectedOrder: []string{"cleanup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if tt.panic {
+							panic("boom")
+						}
+						if tt.fail {
+							return ctx.Machine.Error("failed"), nil
+						}
+						return ctx.Machine.Done("Done"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						order = append(order, "compensate")
+						return nil, nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Plugins: []tango.Plugin[Services, State]{
+					{
+						Cleanup: func(ctx *tango.MachineContext[Services, State]) error {
+							order = append(order, "cleanup")
+							return nil
+						},
+					},
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.panic {
+						t.Errorf("unexpected panic state: %v", r)
+					}
+				}()
+				if _, err := m.Run(); (err != nil) != tt.fail {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+
+			if !reflect.DeepEqual(order, tt.expectedOrder) {
+				t.Errorf("expected order %v, got %v", tt.expectedOrder, order)
+			}
+		})
+	}
+}
+
+type contributeStepsTestCase struct {
+	name          string
+	plugins       []tango.Plugin[Services, State]
+	from          string
+	expectedOrder []string
+}
+
+func TestPlugin_ContributeSteps(t *testing.T) {
+	var order []string
+	step := func(name string) tango.Step[Services, State] {
+		return tango.Step[Services, State]{
+			Name: name,
+			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				order = append(order, name)
+				return ctx.Machine.Next(name), nil
+			},
+		}
+	}
+	contribute := func(names ...string) func(m *tango.Machine[Services, State]) []tango.Step[Services, State] {
+		return func(m *tango.Machine[Services, State]) []tango.Step[Services, State] {
+			var steps []tango.Step[Services, State]
+			for _, name := range names {
+				steps = append(steps, step(name))
+			}
+			return steps
+		}
+	}
+
+	tests := []contributeStepsTestCase{
+		{
+			name: "TrailingAudit",
+			plugins: []tango.Plugin[Services, State]{
+				{ContributeSteps: contribute("Audit")},
+			},
+			expectedOrder: []string{"Step1", "Step2", "Audit"},
+		},
+		{
+			name: "SeveralPlugins",
+			plugins: []tango.Plugin[Services, State]{
+				{ContributeSteps: contribute("Notify"), Priority: 2},
+				{ContributeSteps: contribute("Audit"), Priority: 1},
+				{ContributeSteps: contribute("Authorize"), StepPosition: tango.PrependSteps},
+			},
+			expectedOrder: []string{"Authorize", "Step1", "Step2", "Audit", "Notify"},
+		},
+		{
+			name: "RunFromFirstStep",
+			plugins: []tango.Plugin[Services, State]{
+				{ContributeSteps: contribute("Audit")},
+				{ContributeSteps: contribute("Authorize"), StepPosition: tango.PrependSteps},
+			},
+			from:          "Step1",
+			expectedOrder: []string{"Step1", "Step2", "Audit"},
+		},
+		{
+			name: "RunFromSecondStep",
+			plugins: []tango.Plugin[Services, State]{
+				{ContributeSteps: contribute("Audit")},
+				{ContributeSteps: contribute("Authorize"), StepPosition: tango.PrependSteps},
+			},
+			from:          "Step2",
+			expectedOrder: []string{"Step2", "Audit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Step1"), step("Step2"),
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Plugins: tt.plugins,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			for run := 0; run < 2; run++ {
+				order = nil
+				var err error
+				if tt.from != "" {
+					_, err = m.RunFrom(tt.from)
+				} else {
+					_, err = m.Run()
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(order, tt.expectedOrder) {
+					t.Errorf("expected order %v, got %v", tt.expectedOrder, order)
+				}
+			}
+			if len(m.Steps) != 2 {
+				t.Errorf("expected the machine's steps to be left as configured, got %d steps", len(m.Steps))
+			}
+		})
+	}
+}
+
+type pluginStrategyTestCase struct {
+	name             string
+	modifiers        []string
+	expectedPlugin   string
+	expectedStrategy string
+}
+
+func TestPlugin_StrategyPlugin(t *testing.T) {
+	strategies := map[string]tango.ExecutionStrategy[Services, State]{
+		"concurrency": &tango.ConcurrentStrategy[Services, State]{Concurrency: 2},
+		"sequential":  &tango.SequentialStrategy[Services, State]{},
+		"passive":     nil,
+	}
+
+	tests := []pluginStrategyTestCase{
+		{
+			name:             "NoModifier",
+			expectedStrategy: "SequentialStrategy",
+		},
+		{
+			name:             "LastModifierWins",
+			modifiers:        []string{"sequential", "concurrency"},
+			expectedPlugin:   "concurrency",
+			expectedStrategy: "ConcurrentStrategy",
+		},
+		{
+			name:             "NilStrategyIgnored",
+			modifiers:        []string{"concurrency", "passive"},
+			expectedPlugin:   "concurrency",
+			expectedStrategy: "ConcurrentStrategy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var plugins []tango.Plugin[Services, State]
+			for i, name := range tt.modifiers {
+				strategy := strategies[name]
+				plugins = append(plugins, tango.Plugin[Services, State]{
+					Name:     name,
+					Priority: i,
+					ModifyExecutionStrategy: func(m *tango.Machine[Services, State]) tango.ExecutionStrategy[Services, State] {
+						return strategy
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Plugins: plugins,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if plugin := m.StrategyPlugin(); plugin != tt.expectedPlugin {
+				t.Errorf("expected strategy set by %q, got %q", tt.expectedPlugin, plugin)
+			}
+			if strategy := m.ActiveStrategyName(); strategy != tt.expectedStrategy {
+				t.Errorf("expected active strategy %s, got %s", tt.expectedStrategy, strategy)
+			}
+		})
+	}
+}
+
+type pluginHaltTestCase struct {
+	name             string
+	failFirst        bool
+	haltAfter        string
+	expectedExecuted []string
+	expectedResult   any
+	expectErr        bool
+}
+
+func TestPlugin_Halt(t *testing.T) {
+	tests := []pluginHaltTestCase{
+		{
+			name:             "HaltAfterFirstStep",
+			haltAfter:        "Step1",
+			expectedExecuted: []string{"Step1"},
+			expectedResult:   "Step1",
+		},
+		{
+			name:             "NoHalt",
+			expectedExecuted: []string{"Step1", "Step2", "Step3"},
+			expectedResult:   "Step3",
+		},
+		{
+			name:             "StepErrorTakesPrecedence",
+			failFirst:        true,
+			haltAfter:        "Step1",
+			expectedExecuted: []string{"Step1"},
+			expectErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed []string
+			step := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executed = append(executed, name)
+						if tt.failFirst && name == "Step1" {
+							return ctx.Machine.Error("failed"), nil
+						}
+						if name == "Step3" {
+							return ctx.Machine.Done(name), nil
+						}
+						return ctx.Machine.Next(name), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return nil, nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Step1"), step("Step2"), step("Step3"),
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Plugins: []tango.Plugin[Services, State]{
+					{
+						Name: "policy",
+						Halt: func(ctx *tango.MachineContext[Services, State], step string, response *tango.Response[Services, State]) bool {
+							return step == tt.haltAfter
+						},
+					},
+				},
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+			if tt.expectErr {
+				return
+			}
+			if response == nil || response.Status != tango.DONE || response.Result != tt.expectedResult {
+				t.Errorf("expected DONE response with result %v, got %+v", tt.expectedResult, response)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package tango
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Describer is implemented by every *Machine, whatever its type parameters, so machines of
+// different types can be kept in one Registry. Type-assert a looked up machine to its
+// *Machine type to run it.
+type Describer interface {
+	Describe() MachineInfo
+}
+
+// Registry keeps machines by name for discovery, for example by an admin endpoint listing the
+// application's workflows. It is safe for concurrent use. The zero value is not usable; create
+// one with NewRegistry, or use the package-level functions, which share a default registry.
+type Registry struct {
+	mu       sync.RWMutex
+	machines map[string]Describer
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{machines: make(map[string]Describer)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds a machine under its name. It returns an error if a machine with that name is
+// already registered.
+func (r *Registry) Register(m Describer) error {
+	name := m.Describe().Name
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.machines[name]; ok {
+		return fmt.Errorf("machine %s is already registered", name)
+	}
+	r.machines[name] = m
+	return nil
+}
+
+// Unregister removes the machine registered under name, if any.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.machines, name)
+}
+
+// Lookup returns the machine registered under name.
+func (r *Registry) Lookup(name string) (Describer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.machines[name]
+	return m, ok
+}
+
+// ByTag returns the registered machines that have the tag, sorted by name.
+func (r *Registry) ByTag(tag string) []Describer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.machines))
+	for name, m := range r.machines {
+		if slices.Contains(m.Describe().Tags, tag) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	machines := make([]Describer, 0, len(names))
+	for _, name := range names {
+		machines = append(machines, r.machines[name])
+	}
+	return machines
+}
+
+// RegisterMachine adds a machine to the default registry under its name.
+func RegisterMachine(m Describer) error {
+	return defaultRegistry.Register(m)
+}
+
+// UnregisterMachine removes a machine from the default registry.
+func UnregisterMachine(name string) {
+	defaultRegistry.Unregister(name)
+}
+
+// LookupMachine returns the machine registered under name in the default registry.
+func LookupMachine(name string) (Describer, bool) {
+	return defaultRegistry.Lookup(name)
+}
+
+// MachinesByTag returns the machines in the default registry that have the tag, sorted by name.
+func MachinesByTag(tag string) []Describer {
+	return defaultRegistry.ByTag(tag)
+}
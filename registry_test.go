@@ -0,0 +1,61 @@
+package tango_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type registryTestCase struct {
+	name     string
+	tag      string
+	expected []string
+}
+
+func TestRegistry(t *testing.T) {
+	newMachine := func(name string, tags ...string) *tango.Machine[Services, State] {
+		return tango.NewMachine(name, []tango.Step[Services, State]{tango.NoOpStep[Services, State]("Step1")},
+			&tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{Tags: tags}, &tango.SequentialStrategy[Services, State]{})
+	}
+	machines := []*tango.Machine[Services, State]{
+		newMachine("RegistryBilling", "payments", "nightly"),
+		newMachine("RegistryRefunds", "payments"),
+		newMachine("RegistryReports", "nightly"),
+	}
+	for _, m := range machines {
+		if err := tango.RegisterMachine(m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer tango.UnregisterMachine(m.Name)
+	}
+
+	if err := tango.RegisterMachine(newMachine("RegistryBilling")); err == nil {
+		t.Error("expected registering a duplicate name to fail")
+	}
+	found, ok := tango.LookupMachine("RegistryRefunds")
+	if m, isMachine := found.(*tango.Machine[Services, State]); !ok || !isMachine || m != machines[1] {
+		t.Errorf("expected to look up the registered machine, got %v", found)
+	}
+	if _, ok := tango.LookupMachine("RegistryMissing"); ok {
+		t.Error("expected no machine for an unregistered name")
+	}
+
+	tests := []registryTestCase{
+		{name: "Payments", tag: "payments", expected: []string{"RegistryBilling", "RegistryRefunds"}},
+		{name: "Nightly", tag: "nightly", expected: []string{"RegistryBilling", "RegistryReports"}},
+		{name: "UnknownTag", tag: "hourly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, m := range tango.MachinesByTag(tt.tag) {
+				names = append(names, m.Describe().Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("expected machines %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
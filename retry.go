@@ -0,0 +1,106 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes how often a failing step is retried and how long to wait in between.
+// A step fails when Execute returns an error or a response with status ERROR. A wait ends as
+// soon as the run's context is cancelled, and is not started at all when the context's
+// deadline would pass first.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles after every retry.
+	Backoff time.Duration
+	// Jitter randomizes the delays so that failing callers do not retry in lockstep.
+	Jitter Jitter
+	// Rand, when set, returns the random numbers in [0, 1) used for jitter. By default the
+	// math/rand global source is used.
+	Rand func() float64
+}
+
+// Jitter selects how retry delays are randomized. With d the exponential delay from Delay and
+// r a random number in [0, 1):
+//
+//   - JitterNone waits d
+//   - JitterFull waits r * d, anywhere from 0 up to d
+//   - JitterEqual waits d/2 + r * d/2, keeping at least half of the backoff
+type Jitter int
+
+const (
+	JitterNone Jitter = iota
+	JitterFull
+	JitterEqual
+)
+
+// Delay returns how long to wait before the given retry, counting from 1.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < retry && delay > 0 && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+// JitteredDelay returns how long to wait before the given retry, counting from 1, with the
+// policy's jitter applied to Delay.
+func (p RetryPolicy) JitteredDelay(retry int) time.Duration {
+	delay := p.Delay(retry)
+	random := p.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+	switch p.Jitter {
+	case JitterFull:
+		return time.Duration(random() * float64(delay))
+	case JitterEqual:
+		return delay/2 + time.Duration(random()*float64(delay/2))
+	default:
+		return delay
+	}
+}
+
+// executeWithRetry runs the step's Execute function, retrying it according to the step's policy.
+func (m *Machine[Services, State]) executeWithRetry(ctx *MachineContext[Services, State], step Step[Services, State]) (*Response[Services, State], error) {
+	response, err := m.executor().Execute(ctx, step)
+	if step.Retry == nil {
+		return response, err
+	}
+	for retry := 1; retry < step.Retry.MaxAttempts && (err != nil || response.Status == ERROR); retry++ {
+		backoff := func() error { return sleepBackoff(m.runContext(), step.Retry.JitteredDelay(retry)) }
+		if sleepErr := ctx.slot.yield(m.runContext(), backoff); sleepErr != nil {
+			return nil, fmt.Errorf("step %s retry interrupted: %w", step.Name, sleepErr)
+		}
+		response, err = m.executor().Execute(ctx, step)
+	}
+	return response, err
+}
+
+// sleepBackoff waits for a retry backoff of d like sleepContext, but returns
+// context.DeadlineExceeded at once when ctx's deadline is sooner than d, since the retry
+// after the backoff could not start in time anyway.
+func sleepBackoff(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return fmt.Errorf("backoff of %v ends after the deadline: %w", d, context.DeadlineExceeded)
+	}
+	return sleepContext(ctx, d)
+}
+
+// sleepContext waits for d, returning early with the context's error if ctx ends first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,362 @@
+package tango_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/phr3nzy/tango"
+)
+
+type deadLetterTestCase struct {
+	name             string
+	maxAttempts      int
+	strategyAttempts int
+	expectedAttempts int
+	expectedError    string
+}
+
+func TestMachine_OnDeadLetter(t *testing.T) {
+	tests := []deadLetterTestCase{
+		{
+			name:             "RetriesExhausted",
+			maxAttempts:      3,
+			expectedAttempts: 3,
+			expectedError:    "step Step2 failed: still failing",
+		},
+		{
+			name:             "StrategyRetriesExhausted",
+			maxAttempts:      1,
+			strategyAttempts: 3,
+			expectedAttempts: 3,
+			expectedError:    "step Step2 failed: still failing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			compensated := false
+			var letters []string
+			var strategy tango.ExecutionStrategy[Services, State] = &tango.SequentialStrategy[Services, State]{}
+			if tt.strategyAttempts > 0 {
+				strategy = tango.WithRetry(strategy, tango.RetryPolicy{MaxAttempts: tt.strategyAttempts, Backoff: time.Millisecond})
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = true
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				},
+				{
+					Name:  "Step2",
+					Retry: &tango.RetryPolicy{MaxAttempts: tt.maxAttempts, Backoff: time.Millisecond},
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						attempts++
+						return ctx.Machine.Error("still failing"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+				OnDeadLetter: func(ctx *tango.MachineContext[Services, State], step tango.Step[Services, State], err error) {
+					if !compensated {
+						t.Errorf("expected compensation to run before the dead-letter hook")
+					}
+					letters = append(letters, step.Name+": "+err.Error())
+				},
+			}, strategy)
+
+			_, err := m.Run()
+
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Errorf("expected %v attempts, got %v", tt.expectedAttempts, attempts)
+			}
+			if len(letters) != 1 || letters[0] != "Step2: "+tt.expectedError {
+				t.Errorf("expected one dead letter for Step2, got %v", letters)
+			}
+		})
+	}
+}
+
+type requeueTestCase struct {
+	name             string
+	notReadyFor      int
+	maxRequeues      int
+	expectedAttempts int
+	expectedError    string
+}
+
+func TestMachine_Requeue(t *testing.T) {
+	tests := []requeueTestCase{
+		{
+			name:             "ReadyAfterTwoRequeues",
+			notReadyFor:      2,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "MaxRequeuesExceeded",
+			notReadyFor:      5,
+			maxRequeues:      2,
+			expectedAttempts: 3,
+			expectedError:    "step Poll requeued more than 2 times",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			finished := false
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Poll",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						attempts++
+						if attempts <= tt.notReadyFor {
+							return ctx.Machine.Requeue("not ready", time.Millisecond), nil
+						}
+						return ctx.Machine.Next("ready"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				},
+				{
+					Name: "Finish",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						finished = true
+						return ctx.Machine.Done(ctx.PreviousResult.Result), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				MaxRequeues: tt.maxRequeues,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+
+			if attempts != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, attempts)
+			}
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				if finished {
+					t.Errorf("expected the run to stop at the requeuing step")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != "ready" {
+				t.Errorf("expected result 'ready', got %v", response.Result)
+			}
+			if counts := m.StepExecutionCounts(); counts["Poll"] != 1 {
+				t.Errorf("expected Poll to be recorded once, got %d", counts["Poll"])
+			}
+		})
+	}
+}
+
+func TestConcurrentStrategy_Requeue(t *testing.T) {
+	tests := []requeueTestCase{
+		{
+			name:             "ReadyAfterTwoRequeues",
+			notReadyFor:      2,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "MaxRequeuesExceeded",
+			notReadyFor:      5,
+			maxRequeues:      2,
+			expectedAttempts: 3,
+			expectedError:    "step Poll requeued more than 2 times",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			var finished atomic.Bool
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Poll",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if int(attempts.Add(1)) <= tt.notReadyFor {
+							return ctx.Machine.Requeue("not ready", time.Millisecond), nil
+						}
+						return ctx.Machine.Next("ready"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				},
+				{
+					Name:         "Finish",
+					MustRunAfter: []string{"Poll"},
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						finished.Store(true)
+						return ctx.Machine.Done("finished"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				MaxRequeues: tt.maxRequeues,
+			}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 2})
+
+			response, err := m.Run()
+
+			if int(attempts.Load()) != tt.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.expectedAttempts, attempts.Load())
+			}
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				if finished.Load() {
+					t.Errorf("expected the dependent step not to run")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response == nil || response.Result != "finished" {
+				t.Errorf("expected result 'finished', got %v", response)
+			}
+			if counts := m.StepExecutionCounts(); counts["Poll"] != 1 {
+				t.Errorf("expected Poll to be recorded once, got %d", counts["Poll"])
+			}
+		})
+	}
+}
+
+type jitterTestCase struct {
+	name        string
+	jitter      tango.Jitter
+	retry       int
+	expectedMin time.Duration
+	expectedMax time.Duration
+}
+
+func TestRetryPolicy_JitteredDelay(t *testing.T) {
+	tests := []jitterTestCase{
+		{
+			name:        "None",
+			jitter:      tango.JitterNone,
+			retry:       3,
+			expectedMin: 400 * time.Millisecond,
+			expectedMax: 400 * time.Millisecond,
+		},
+		{
+			name:        "Full",
+			jitter:      tango.JitterFull,
+			retry:       3,
+			expectedMin: 0,
+			expectedMax: 400 * time.Millisecond,
+		},
+		{
+			name:        "Equal",
+			jitter:      tango.JitterEqual,
+			retry:       3,
+			expectedMin: 200 * time.Millisecond,
+			expectedMax: 400 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := rand.New(rand.NewSource(1))
+			policy := tango.RetryPolicy{Backoff: 100 * time.Millisecond, Jitter: tt.jitter, Rand: source.Float64}
+
+			lowest, highest := time.Duration(math.MaxInt64), time.Duration(0)
+			for i := 0; i < 1000; i++ {
+				delay := policy.JitteredDelay(tt.retry)
+				if delay < tt.expectedMin || delay > tt.expectedMax {
+					t.Fatalf("expected delay within [%v, %v], got %v", tt.expectedMin, tt.expectedMax, delay)
+				}
+				lowest, highest = min(lowest, delay), max(highest, delay)
+			}
+
+			spread := tt.expectedMax - tt.expectedMin
+			if highest-lowest < spread*9/10 {
+				t.Errorf("expected delays to spread over [%v, %v], got [%v, %v]", tt.expectedMin, tt.expectedMax, lowest, highest)
+			}
+		})
+	}
+}
+
+type retryBackoffContextTestCase struct {
+	name          string
+	newContext    func() (context.Context, context.CancelFunc)
+	expectedError error
+}
+
+func TestMachine_Step_RetryBackoffContext(t *testing.T) {
+	tests := []retryBackoffContextTestCase{
+		{
+			name: "CancelledDuringBackoff",
+			newContext: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(20*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			expectedError: context.Canceled,
+		},
+		{
+			name: "DeadlineBeforeBackoffEnds",
+			newContext: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 5*time.Second)
+			},
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Charge",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						attempts++
+						return nil, errors.New("gateway unavailable")
+					},
+					Retry: &tango.RetryPolicy{MaxAttempts: 3, Backoff: time.Minute},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			ctx, cancel := tt.newContext()
+			defer cancel()
+
+			start := time.Now()
+			_, err := m.RunContext(ctx)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("expected the run to return promptly, took %v", elapsed)
+			}
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected error wrapping %v, got %v", tt.expectedError, err)
+			}
+			if attempts != 1 {
+				t.Errorf("expected 1 attempt, got %d", attempts)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package tango
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 )
 
 // ExecutionStrategy defines the interface for different execution strategies.
@@ -10,83 +14,240 @@ type ExecutionStrategy[Services, State any] interface {
 	Compensate(m *Machine[Services, State]) (*Response[Services, State], error)
 }
 
+// CloneableStrategy is an ExecutionStrategy that keeps state between runs. Machine.Clone gives
+// the clone its own copy of such a strategy; other strategies are shared with the clone, so
+// they must be safe to use from several machines at once.
+type CloneableStrategy[Services, State any] interface {
+	ExecutionStrategy[Services, State]
+	// Clone returns a copy of the strategy for a cloned machine.
+	Clone() ExecutionStrategy[Services, State]
+}
+
+// cloneStrategy returns the strategy a clone of a machine running s uses.
+func cloneStrategy[Services, State any](s ExecutionStrategy[Services, State]) ExecutionStrategy[Services, State] {
+	if cloneable, ok := s.(CloneableStrategy[Services, State]); ok {
+		return cloneable.Clone()
+	}
+	return s
+}
+
 // SequentialStrategy is a default implementation of ExecutionStrategy that runs steps sequentially.
 type SequentialStrategy[Services, State any] struct{}
 
 func (s *SequentialStrategy[Services, State]) Execute(m *Machine[Services, State]) (*Response[Services, State], error) {
-	for i := 0; i < len(m.Steps); i++ {
+	requeues, restarts := 0, 0
+	for i := m.start; i < len(m.Steps); i++ {
 		step := m.Steps[i]
 
-		response, err := m.executeStep(step)
+		if err := m.checkStop(step); err != nil {
+			return m.stop(err)
+		}
+
+		if step.Barrier || !m.stepEnabled(step) {
+			continue
+		}
+
+		original := step
+		step, response, err := m.executeWithFallback(m.Context, step)
 		if err != nil {
+			if m.tolerate(original, err) {
+				continue
+			}
+			if target, ok := m.recoveryTarget(err); ok {
+				m.Context.PreviousResult = m.Error(err)
+				if i, err = s.jump(m, step, target); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			err = m.budgetExhausted(original, err)
+			m.deadLetter(step, err)
 			return nil, err
 		}
 
-		m.mu.Lock()
-		m.ExecutedSteps = append(m.ExecutedSteps, step)
-		m.Context.PreviousResult = response
-		m.mu.Unlock()
+		if response.Status == REQUEUE {
+			if requeues >= m.maxRequeues() {
+				err := fmt.Errorf("step %s requeued more than %d times", step.Name, m.maxRequeues())
+				return m.fail(step, FailureInfo{Step: step.Name, Result: response.Result, Err: err}, err)
+			}
+			requeues++
+			if err := sleepContext(m.runContext(), response.RequeueAfter); err != nil {
+				return nil, fmt.Errorf("step %s requeue interrupted: %w", step.Name, err)
+			}
+			i--
+			continue
+		}
+		requeues = 0
+
+		if response.Status == RESTART && restarts >= m.maxRestarts() {
+			err := fmt.Errorf("step %s restarted the machine more than %d times", step.Name, m.maxRestarts())
+			return m.fail(step, FailureInfo{Step: step.Name, Result: response.Result, Err: err}, err)
+		}
+
+		m.recordStep(step, response)
+
+		if step.DoneIf != nil && step.DoneIf(m.Context) {
+			return m.Done(response.Result), nil
+		}
+		if m.Config.StopCondition != nil && m.Config.StopCondition(m.Context) {
+			return m.Done(response.Result), nil
+		}
+		if m.halted(step, response) {
+			return m.Done(response.Result), nil
+		}
 
 		switch response.Status {
-		case NEXT:
+		case NEXT, SAVEPOINT:
 			continue
+		case RESTART:
+			restarts++
+			m.restart()
+			i = -1
 		case DONE:
 			return response, nil
+		case SUSPEND:
+			return m.suspend(step, response)
 		case ERROR:
-			cResponse, err := m.Compensate()
-			if err != nil {
-				return nil, fmt.Errorf("compensate error: %v", err)
+			err := fmt.Errorf("step %s failed: %v", step.Name, response.Result)
+			if m.tolerate(original, err) {
+				continue
 			}
-			return cResponse, fmt.Errorf("step %s failed: %v", step.Name, response.Result)
+			if resultErr, ok := response.Result.(error); ok {
+				if target, ok := m.recoveryTarget(resultErr); ok {
+					if i, err = s.jump(m, step, target); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			return m.fail(step, FailureInfo{Step: step.Name, Result: response.Result}, m.budgetExhausted(original, err))
 		case SKIP:
+			m.trackSkipped(i+1, i+1+response.SkipCount)
 			i += response.SkipCount
 		case JUMP:
-			targetIndex := -1
-			for index, s := range m.Steps {
-				if s.Name == response.JumpTarget {
-					targetIndex = index
-					break
-				}
-			}
+			targetIndex := m.stepIndex(response.JumpTarget)
 			if targetIndex >= 0 {
+				m.trackSkipped(i+1, targetIndex)
 				i = targetIndex - 1
-			} else {
-				return nil, fmt.Errorf("jump target '%s' not found at %s", response.JumpTarget, step.Name)
+				continue
+			}
+			err := fmt.Errorf("jump target '%s' not found at %s", response.JumpTarget, step.Name)
+			switch m.Config.OnMissingJump {
+			case MissingJumpIgnore:
+				continue
+			case MissingJumpCompensate:
+				return m.fail(step, FailureInfo{Step: step.Name, Result: response.Result, Err: err}, err)
+			default:
+				return nil, err
 			}
+		default:
+			handler, ok := m.Config.StatusHandlers[response.Status]
+			if !ok {
+				return nil, fmt.Errorf("step %s returned unknown status %s", step.Name, response.Status)
+			}
+			control := handler(m, response, i)
+			if control.Err != nil {
+				err := fmt.Errorf("step %s failed: %w", step.Name, control.Err)
+				return m.fail(step, FailureInfo{Step: step.Name, Result: response.Result, Err: control.Err}, err)
+			}
+			if control.Response != nil {
+				return control.Response, nil
+			}
+			i = control.Next - 1
 		}
 	}
 
 	return nil, nil
 }
 
-// Compensate runs the compensate functions of the executed steps.
+// jump returns the loop index that resumes a sequential run at the target step.
+func (s *SequentialStrategy[Services, State]) jump(m *Machine[Services, State], step Step[Services, State], target string) (int, error) {
+	targetIndex := m.stepIndex(target)
+	if targetIndex < 0 {
+		return 0, fmt.Errorf("recovery target '%s' not found at %s", target, step.Name)
+	}
+	return targetIndex - 1, nil
+}
+
+// Compensate runs the compensate functions of the executed steps in reverse order, stopping at
+// the most recent step that returned SAVEPOINT. While a
+// step's BeforeCompensate, Compensate and AfterCompensate functions run, ctx.PreviousResult
+// is the response that step's Execute produced; it is restored once the walk ends.
 func (s *SequentialStrategy[Services, State]) Compensate(m *Machine[Services, State]) (*Response[Services, State], error) {
 	m.Context = m.InitialContext
-	for i := len(m.ExecutedSteps) - 1; i >= 0; i-- {
-		step := m.ExecutedSteps[i]
-		if step.BeforeCompensate != nil {
-			if err := step.BeforeCompensate(m.Context); err != nil {
-				return nil, err
-			}
+	ctx := m.runContext()
+	previous := m.Context.PreviousResult
+	defer func() { m.Context.PreviousResult = previous }()
+
+	history := sinceSavepoint[Services, State]{m.history()}
+	total := history.Len()
+	var compensated, pending []string
+	var errs []error
+
+	history.Reverse(func(step Step[Services, State], response *Response[Services, State]) bool {
+		if ctx.Err() != nil {
+			pending = append(pending, step.Name)
+			return true
 		}
-		if step.Compensate == nil {
-			return nil, fmt.Errorf("step %s has no compensate function", step.Name)
+		if step.CompensateIf != nil && !step.CompensateIf(m.failure) {
+			return true
 		}
-		if _, err := step.Compensate(m.Context); err != nil {
-			return nil, err
+		m.Context.PreviousResult = response
+		if err := m.compensateStep(step); err != nil {
+			errs = append(errs, err)
+			return m.Config.CompensateAllOnError
 		}
-		if step.AfterCompensate != nil {
-			if err := step.AfterCompensate(m.Context); err != nil {
-				return nil, err
-			}
+		compensated = append(compensated, step.Name)
+		if m.Config.OnCompensateProgress != nil {
+			m.Config.OnCompensateProgress(len(compensated), total)
 		}
+		return true
+	})
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if pending != nil {
+		return nil, &CompensationError{Compensated: compensated, Pending: pending, Err: ctx.Err()}
+	}
+	if m.Config.TrackSkipped && m.Config.CompensateSkipped {
+		return nil, s.compensateSkipped(m)
 	}
 	return nil, nil
 }
 
-// ConcurrentStrategy runs steps concurrently.
+// compensateSkipped compensates the tracked skipped steps, most recent first.
+func (s *SequentialStrategy[Services, State]) compensateSkipped(m *Machine[Services, State]) error {
+	var errs []error
+	for i := len(m.skipped) - 1; i >= 0; i-- {
+		step := m.skipped[i]
+		if m.compensateFunc(step) == nil {
+			continue
+		}
+		if err := m.compensateStep(step); err != nil {
+			errs = append(errs, err)
+			if !m.Config.CompensateAllOnError {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ConcurrentStrategy runs steps concurrently. Steps sharing a Key run once. A step that
+// returns REQUEUE runs again after its delay without holding up the other steps.
 type ConcurrentStrategy[Services, State any] struct {
 	Concurrency int
+	// RaceMode returns the first DONE response as soon as it arrives and cancels the context
+	// of the steps still running. The run waits for them to return before it ends. Steps are
+	// scheduled as in a normal run: once per Key, and each after the steps it must run after.
+	RaceMode bool
+	// CompensateConcurrency caps how many compensate functions run at once. Zero or one, the
+	// default, compensates the executed steps one at a time in reverse order, which is always
+	// safe. Above one, steps compensate concurrently as long as no executed step depends on
+	// them through MustRunAfter; a step with dependents waits until they are compensated.
+	// Compensate functions with a Step.CompensateTimeout still run one at a time.
+	CompensateConcurrency int
 }
 
 func (c *ConcurrentStrategy[Services, State]) Execute(m *Machine[Services, State]) (*Response[Services, State], error) {
@@ -94,25 +255,60 @@ func (c *ConcurrentStrategy[Services, State]) Execute(m *Machine[Services, State
 		return (&SequentialStrategy[Services, State]{}).Execute(m)
 	}
 
+	m.concurrent.Store(true)
+	defer m.concurrent.Store(false)
+
+	scheduled, err := c.schedule(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.RaceMode {
+		return c.race(m, scheduled)
+	}
+
 	sem := make(chan struct{}, c.Concurrency)
 	responseChan := make(chan *Response[Services, State], len(m.Steps))
-	errorChan := make(chan error, len(m.Steps))
+	errorChan := make(chan stepFailure[Services, State], len(m.Steps))
 
-	for i := 0; i < len(m.Steps); i++ {
+	var stopErr error
+	finished := newCompletion(scheduled)
+
+	previous := m.previousResult()
+	for i, step := range scheduled {
+		if step.Barrier {
+			waitAll(sem)
+			if len(errorChan) > 0 {
+				break
+			}
+			finished.pass(i)
+			previous = m.previousResult()
+			continue
+		}
 		sem <- struct{}{}
-		go func(step Step[Services, State]) {
+		if err := m.checkStop(step); err != nil {
+			<-sem
+			stopErr = err
+			break
+		}
+		go func(step Step[Services, State], self *stepDone) {
 			defer func() { <-sem }()
-			response, err := m.executeStep(step)
-			if err != nil {
-				errorChan <- err
+			defer close(self.done)
+			if !finished.wait(step.MustRunAfter) {
 				return
 			}
-			responseChan <- response
-			m.mu.Lock()
-			m.ExecutedSteps = append(m.ExecutedSteps, step)
-			m.Context.PreviousResult = response
-			m.mu.Unlock()
-		}(m.Steps[i])
+			ctx, merge := m.stepContext(previous)
+			response, failure := m.executeConcurrent(ctx, step)
+			merge()
+			if failure != nil {
+				errorChan <- *failure
+				return
+			}
+			if response != nil {
+				responseChan <- response
+			}
+			self.ok = true
+		}(step, finished.steps[i])
 	}
 
 	for i := 0; i < c.Concurrency; i++ {
@@ -122,14 +318,12 @@ func (c *ConcurrentStrategy[Services, State]) Execute(m *Machine[Services, State
 	close(responseChan)
 	close(errorChan)
 
-	select {
-	case <-errorChan:
-		cResponse, err := m.Compensate()
-		if err != nil {
-			return nil, fmt.Errorf("compensate error: %v", err)
-		}
-		return cResponse, err
-	default:
+	if stopErr != nil {
+		return m.stop(stopErr)
+	}
+
+	if failure, ok := <-errorChan; ok {
+		return m.fail(failure.step, FailureInfo{Step: failure.step.Name, Result: failure.result, Err: failure.err}, failure.err)
 	}
 
 	for response := range responseChan {
@@ -141,53 +335,389 @@ func (c *ConcurrentStrategy[Services, State]) Execute(m *Machine[Services, State
 	return nil, nil
 }
 
-// Compensate runs the compensate functions of the executed steps.
+// executeConcurrent runs a step scheduled by ConcurrentStrategy and records its response. A
+// step that returns REQUEUE runs again after the requested delay, in its own goroutine, while
+// the other steps carry on. A returned error or an ERROR response is a failure, which ends the
+// run unless the scheduled step is allowed to fail; a tolerated failure returns neither a
+// response nor a failure.
+func (m *Machine[Services, State]) executeConcurrent(ctx *MachineContext[Services, State], step Step[Services, State]) (*Response[Services, State], *stepFailure[Services, State]) {
+	original := step
+	step, response, err := m.executeWithFallback(ctx, original)
+	for requeues := 0; err == nil && response.Status == REQUEUE; requeues++ {
+		if requeues >= m.maxRequeues() {
+			err := fmt.Errorf("step %s requeued more than %d times", step.Name, m.maxRequeues())
+			return nil, &stepFailure[Services, State]{step: step, result: response.Result, err: err}
+		}
+		if err := sleepContext(m.runContext(), response.RequeueAfter); err != nil {
+			return nil, &stepFailure[Services, State]{step: step, err: fmt.Errorf("step %s requeue interrupted: %w", step.Name, err)}
+		}
+		step, response, err = m.executeWithFallback(ctx, original)
+	}
+	var result any
+	if err == nil {
+		m.recordStep(step, response)
+		if response.Status != ERROR {
+			return response, nil
+		}
+		result = response.Result
+		err = fmt.Errorf("step %s failed: %v", step.Name, response.Result)
+	}
+	if m.tolerate(original, err) {
+		return nil, nil
+	}
+	return nil, &stepFailure[Services, State]{step: step, result: result, err: m.budgetExhausted(original, err)}
+}
+
+// fail compensates the run after step failed and reports the failure to the dead-letter hook.
+// It returns the compensation response with stepErr, or the compensation error if rolling back
+// failed.
+func (m *Machine[Services, State]) fail(step Step[Services, State], failure FailureInfo, stepErr error) (*Response[Services, State], error) {
+	m.failure = failure
+	cResponse, err := m.Compensate()
+	if err != nil {
+		err = fmt.Errorf("compensate error: %w", err)
+		m.deadLetter(step, err)
+		return nil, err
+	}
+	m.deadLetter(step, stepErr)
+	return cResponse, stepErr
+}
+
+// maxRestarts returns how many times a run may restart.
+func (m *Machine[Services, State]) maxRestarts() int {
+	if m.Config.MaxRestarts > 0 {
+		return m.Config.MaxRestarts
+	}
+	return DefaultMaxRestarts
+}
+
+// restart clears the steps executed so far, which a restarted run no longer compensates.
+// The decision log and execution counts keep covering the whole run.
+func (m *Machine[Services, State]) restart() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clearHistory()
+	m.skipped = nil
+}
+
+// resetAttempt clears the history of a failed attempt before the run is tried again, so that
+// the next attempt neither compensates nor reports the steps of the previous one.
+func (m *Machine[Services, State]) resetAttempt(previous *Response[Services, State]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clearHistory()
+	m.decisions = nil
+	m.skipped = nil
+	m.Context.PreviousResult = previous
+	m.publishView()
+}
+
+// maxRequeues returns how many times in a row a step may requeue.
+func (m *Machine[Services, State]) maxRequeues() int {
+	if m.Config.MaxRequeues > 0 {
+		return m.Config.MaxRequeues
+	}
+	return DefaultMaxRequeues
+}
+
+// schedule returns the steps a concurrent run executes, in the order it starts them: the
+// enabled steps from the run's first step on, once per Key, each after the steps it must run
+// after.
+func (c *ConcurrentStrategy[Services, State]) schedule(m *Machine[Services, State]) ([]Step[Services, State], error) {
+	keys := make(map[string]bool)
+	var scheduled []Step[Services, State]
+	for i := m.start; i < len(m.Steps); i++ {
+		if !m.stepEnabled(m.Steps[i]) {
+			continue
+		}
+		if key := m.Steps[i].Key; key != "" {
+			if keys[key] {
+				continue
+			}
+			keys[key] = true
+		}
+		scheduled = append(scheduled, m.Steps[i])
+	}
+	return dependencyOrder(scheduled)
+}
+
+// race runs the scheduled steps concurrently until one of them returns DONE, then cancels the
+// others.
+func (c *ConcurrentStrategy[Services, State]) race(m *Machine[Services, State], scheduled []Step[Services, State]) (*Response[Services, State], error) {
+	parent := m.runContext()
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	m.setRunContext(ctx)
+	defer m.setRunContext(parent)
+
+	sem := make(chan struct{}, c.Concurrency)
+	winner := make(chan *Response[Services, State], 1)
+	errorChan := make(chan stepFailure[Services, State], len(m.Steps))
+
+	var stopErr error
+	finished := newCompletion(scheduled)
+
+	previous := m.previousResult()
+	for i, step := range scheduled {
+		if step.Barrier {
+			waitAll(sem)
+			if len(winner) > 0 || len(errorChan) > 0 {
+				break
+			}
+			finished.pass(i)
+			previous = m.previousResult()
+			continue
+		}
+		sem <- struct{}{}
+		if len(winner) > 0 {
+			<-sem
+			break
+		}
+		if err := m.checkStop(step); err != nil {
+			<-sem
+			if len(winner) == 0 {
+				stopErr = err
+			}
+			break
+		}
+		go func(step Step[Services, State], self *stepDone) {
+			defer func() { <-sem }()
+			defer close(self.done)
+			if !finished.wait(step.MustRunAfter) || len(winner) > 0 {
+				return
+			}
+			ctx, merge := m.stepContext(previous)
+			response, failure := m.executeConcurrent(ctx, step)
+			merge()
+			if failure != nil {
+				errorChan <- *failure
+				return
+			}
+			self.ok = true
+			if response != nil && response.Status == DONE {
+				select {
+				case winner <- response:
+					cancel()
+				default:
+				}
+			}
+		}(step, finished.steps[i])
+	}
+
+	for i := 0; i < c.Concurrency; i++ {
+		sem <- struct{}{}
+	}
+
+	close(errorChan)
+
+	if len(winner) > 0 {
+		return <-winner, nil
+	}
+
+	if stopErr != nil {
+		m.setRunContext(parent)
+		return m.stop(stopErr)
+	}
+
+	if failure, ok := <-errorChan; ok {
+		m.setRunContext(parent)
+		return m.fail(failure.step, FailureInfo{Step: failure.step.Name, Result: failure.result, Err: failure.err}, failure.err)
+	}
+
+	return nil, nil
+}
+
+// waitAll waits until every slot of sem is free.
+func waitAll(sem chan struct{}) {
+	for i := 0; i < cap(sem); i++ {
+		sem <- struct{}{}
+	}
+	for i := 0; i < cap(sem); i++ {
+		<-sem
+	}
+}
+
+// stepDone tracks whether a step scheduled by ConcurrentStrategy has finished, and whether
+// it succeeded. ok is written before done is closed.
+type stepDone struct {
+	done chan struct{}
+	ok   bool
+}
+
+// completion tracks the scheduled steps of a concurrent run. steps is indexed like the
+// schedule, and named maps a step name to the indices of the scheduled steps with that name,
+// all of which a dependent waits for.
+type completion struct {
+	steps []*stepDone
+	named map[string][]int
+}
+
+// newCompletion returns a completion tracking scheduled.
+func newCompletion[Services, State any](scheduled []Step[Services, State]) *completion {
+	c := &completion{steps: make([]*stepDone, len(scheduled)), named: make(map[string][]int, len(scheduled))}
+	for i, step := range scheduled {
+		c.steps[i] = &stepDone{done: make(chan struct{})}
+		c.named[step.Name] = append(c.named[step.Name], i)
+	}
+	return c
+}
+
+// pass marks the scheduled step at i, a barrier, as passed, so that the steps that must run
+// after it can start.
+func (c *completion) pass(i int) {
+	c.steps[i].ok = true
+	close(c.steps[i].done)
+}
+
+// wait waits until the steps named in dependencies have finished, and reports whether they
+// all succeeded.
+func (c *completion) wait(dependencies []string) bool {
+	for _, dependency := range dependencies {
+		for _, i := range c.named[dependency] {
+			<-c.steps[i].done
+			if !c.steps[i].ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stepFailure pairs a failed step with its error and, for an ERROR response, its result.
+type stepFailure[Services, State any] struct {
+	step   Step[Services, State]
+	result any
+	err    error
+}
+
+// Compensate runs the compensate functions of the steps executed after the most recent
+// savepoint. Unless CompensateConcurrency is above 1 it rolls back sequentially, like
+// SequentialStrategy. Otherwise the compensations run concurrently, except that a step is only
+// compensated once every executed step that names it in MustRunAfter has been, so rollbacks
+// follow the reverse of the dependency order. The steps share the context, so
+// ctx.PreviousResult is left as it is rather than set to each step's own response.
 func (c *ConcurrentStrategy[Services, State]) Compensate(m *Machine[Services, State]) (*Response[Services, State], error) {
-	if c.Concurrency <= 1 {
+	if c.CompensateConcurrency <= 1 {
 		return (&SequentialStrategy[Services, State]{}).Compensate(m)
 	}
 
-	sem := make(chan struct{}, c.Concurrency)
-	errorChan := make(chan error, len(m.ExecutedSteps))
+	var steps []Step[Services, State]
+	sinceSavepoint[Services, State]{m.history()}.Reverse(func(step Step[Services, State], _ *Response[Services, State]) bool {
+		steps = append(steps, step)
+		return true
+	})
+	total := len(steps)
+	sem := make(chan struct{}, c.CompensateConcurrency)
+	errorChan := make(chan error, total)
+	ctx := m.runContext()
+
+	// dependents maps a step name to the channels closed once the compensations of the steps
+	// that must run after it are done. Those steps executed later, so they are started first
+	// and a compensation only ever waits for ones already started.
+	dependents := make(map[string][]chan struct{})
 
-	for i := len(m.ExecutedSteps) - 1; i >= 0; i-- {
+	var compensatedMu sync.Mutex
+	var compensated, pending []string
+
+	for _, step := range steps {
+		done := make(chan struct{})
+		wait := dependents[step.Name]
+		for _, dependency := range step.MustRunAfter {
+			dependents[dependency] = append(dependents[dependency], done)
+		}
+		if ctx.Err() != nil {
+			pending = append(pending, step.Name)
+			close(done)
+			continue
+		}
 		sem <- struct{}{}
-		go func(step Step[Services, State]) {
+		go func(step Step[Services, State], done chan struct{}) {
 			defer func() { <-sem }()
+			defer close(done)
 
-			if step.BeforeCompensate != nil {
-				if err := step.BeforeCompensate(m.Context); err != nil {
-					errorChan <- err
-					return
-				}
+			for _, dependent := range wait {
+				<-dependent
 			}
-			if step.Compensate == nil {
-				errorChan <- fmt.Errorf("step %s has no compensate function", step.Name)
+			if step.CompensateIf != nil && !step.CompensateIf(m.failure) {
 				return
 			}
-			if _, err := step.Compensate(m.Context); err != nil {
+			if err := m.compensateStep(step); err != nil {
 				errorChan <- err
 				return
 			}
-			if step.AfterCompensate != nil {
-				if err := step.AfterCompensate(m.Context); err != nil {
-					errorChan <- err
-					return
-				}
+			compensatedMu.Lock()
+			compensated = append(compensated, step.Name)
+			if m.Config.OnCompensateProgress != nil {
+				m.Config.OnCompensateProgress(len(compensated), total)
 			}
-		}(m.ExecutedSteps[i])
+			compensatedMu.Unlock()
+		}(step, done)
 	}
 
-	for i := 0; i < c.Concurrency; i++ {
+	for i := 0; i < c.CompensateConcurrency; i++ {
 		sem <- struct{}{}
 	}
 
 	close(errorChan)
 
-	select {
-	case err := <-errorChan:
+	if err, ok := <-errorChan; ok {
 		return nil, err
-	default:
-		return nil, nil
 	}
+
+	if pending != nil {
+		return nil, &CompensationError{Compensated: compensated, Pending: pending, Err: ctx.Err()}
+	}
+	return nil, nil
+}
+
+// NoOpStrategy records the steps a machine would execute and compensate without running
+// them. It is meant for tests.
+type NoOpStrategy[Services, State any] struct {
+	Executed    []string
+	Compensated []string
+}
+
+func (n *NoOpStrategy[Services, State]) Execute(m *Machine[Services, State]) (*Response[Services, State], error) {
+	for _, step := range m.Steps[m.start:] {
+		if step.Barrier {
+			continue
+		}
+		n.Executed = append(n.Executed, step.Name)
+	}
+	return nil, nil
+}
+
+// Compensate records the executed steps in the order they would be compensated.
+func (n *NoOpStrategy[Services, State]) Compensate(m *Machine[Services, State]) (*Response[Services, State], error) {
+	sinceSavepoint[Services, State]{m.history()}.Reverse(func(step Step[Services, State], _ *Response[Services, State]) bool {
+		n.Compensated = append(n.Compensated, step.Name)
+		return true
+	})
+	return nil, nil
+}
+
+// Clone returns a NoOpStrategy that has recorded nothing yet.
+func (n *NoOpStrategy[Services, State]) Clone() ExecutionStrategy[Services, State] {
+	return &NoOpStrategy[Services, State]{}
+}
+
+// CompensationError reports a compensation walk that was cancelled before every executed step was rolled back.
+type CompensationError struct {
+	Compensated []string
+	Pending     []string
+	Err         error
+}
+
+func (e *CompensationError) Error() string {
+	return fmt.Sprintf(
+		"compensation interrupted: %v (compensated: [%s], pending: [%s])",
+		e.Err,
+		strings.Join(e.Compensated, ", "),
+		strings.Join(e.Pending, ", "),
+	)
+}
+
+// Unwrap returns the context error that interrupted the compensation.
+func (e *CompensationError) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,1554 @@
+package tango_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/phr3nzy/tango"
+)
+
+type concurrentKeyTestCase struct {
+	name           string
+	keys           []string
+	expectedCounts map[string]int
+}
+
+func TestConcurrentStrategy_Key(t *testing.T) {
+	tests := []concurrentKeyTestCase{
+		{
+			name:           "DuplicateKeysRunOnce",
+			keys:           []string{"fetch-users", "fetch-users", "fetch-orders", "", "", "fetch-orders"},
+			expectedCounts: map[string]int{"fetch-users": 1, "fetch-orders": 1, "": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			counts := make(map[string]int)
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 3})
+
+			for i, key := range tt.keys {
+				m.AddStep(tango.Step[Services, State]{
+					Name: fmt.Sprintf("Step%d", i),
+					Key:  key,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						mu.Lock()
+						counts[key]++
+						mu.Unlock()
+						return ctx.Machine.Next("Next"), nil
+					},
+				})
+			}
+
+			if _, err := m.Run(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(counts, tt.expectedCounts) {
+				t.Errorf("expected executions per key %v, got %v", tt.expectedCounts, counts)
+			}
+		})
+	}
+}
+
+func TestNoOpStrategy(t *testing.T) {
+	strategy := &tango.NoOpStrategy[Services, State]{}
+	m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+		{
+			Name: "Step1",
+			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				t.Errorf("expected Step1 not to execute")
+				return ctx.Machine.Next("Next"), nil
+			},
+		},
+		{
+			Name: "Step2",
+			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				t.Errorf("expected Step2 not to execute")
+				return ctx.Machine.Done("Done"), nil
+			},
+		},
+	}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+		Log: false,
+	}, strategy)
+
+	if _, err := m.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"Step1", "Step2"}
+	if !reflect.DeepEqual(strategy.Executed, expected) {
+		t.Errorf("expected recorded steps %v, got %v", expected, strategy.Executed)
+	}
+	if len(m.ExecutedSteps) != 0 {
+		t.Errorf("expected no executed steps, got %v", len(m.ExecutedSteps))
+	}
+}
+
+type savepointTestCase struct {
+	name                string
+	savepoints          map[string]bool
+	expectedCompensated []string
+}
+
+func TestSequentialStrategy_Savepoint(t *testing.T) {
+	tests := []savepointTestCase{
+		{
+			name:                "NoSavepoint",
+			expectedCompensated: []string{"Step4", "Step3", "Step2", "Step1"},
+		},
+		{
+			name:                "OneSavepoint",
+			savepoints:          map[string]bool{"Step2": true},
+			expectedCompensated: []string{"Step4", "Step3"},
+		},
+		{
+			name:                "MostRecentSavepointWins",
+			savepoints:          map[string]bool{"Step1": true, "Step3": true},
+			expectedCompensated: []string{"Step4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compensated []string
+			var steps []tango.Step[Services, State]
+			for i := 1; i <= 4; i++ {
+				name := fmt.Sprintf("Step%d", i)
+				last := i == 4
+				steps = append(steps, tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if last {
+							return ctx.Machine.Error("failed"), nil
+						}
+						if tt.savepoints[name] {
+							return ctx.Machine.Savepoint(name), nil
+						}
+						return ctx.Machine.Next(name), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, name)
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+type compensateTimeoutTestCase struct {
+	name                string
+	compensateAll       bool
+	expectedCompensated []string
+}
+
+func TestSequentialStrategy_CompensateTimeout(t *testing.T) {
+	tests := []compensateTimeoutTestCase{
+		{
+			name:                "StopsAtTimeout",
+			expectedCompensated: []string{"Step3"},
+		},
+		{
+			name:                "CompensateAllOnError",
+			compensateAll:       true,
+			expectedCompensated: []string{"Step3", "Step1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compensated []string
+			returned := make(chan struct{})
+
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					return nil, nil
+				}
+			}
+			next := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Step1", Execute: next, Compensate: compensate("Step1")},
+				{
+					Name:              "Step2",
+					Execute:           next,
+					CompensateTimeout: 10 * time.Millisecond,
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						defer close(returned)
+						<-ctx.Context().Done()
+						return nil, ctx.Context().Err()
+					},
+				},
+				{
+					Name: "Step3",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("failed"), nil
+					},
+					Compensate: compensate("Step3"),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				CompensateAllOnError: tt.compensateAll,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, err := m.Run()
+			if err == nil || !strings.Contains(err.Error(), "step Step2 compensate timed out after 10ms") {
+				t.Fatalf("expected a compensate timeout error, got %v", err)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+			select {
+			case <-returned:
+			case <-time.After(time.Second):
+				t.Error("expected the timed out compensate function to see its context cancelled and return")
+			}
+		})
+	}
+}
+
+type concurrentCompensateTimeoutTestCase struct {
+	name                  string
+	steps                 int
+	compensateConcurrency int
+	expectedCounter       int
+}
+
+func TestConcurrentStrategy_CompensateTimeout(t *testing.T) {
+	tests := []concurrentCompensateTimeoutTestCase{
+		{
+			name:                  "ParallelCompensationsKeepState",
+			steps:                 4,
+			compensateConcurrency: 4,
+			expectedCounter:       4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := make([]tango.Step[Services, State], tt.steps)
+			for i := range steps {
+				steps[i] = tango.Step[Services, State]{
+					Name: fmt.Sprintf("Step%d", i),
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(nil), nil
+					},
+					CompensateTimeout: time.Second,
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						counter := ctx.State.Counter
+						time.Sleep(5 * time.Millisecond)
+						ctx.State.Counter = counter + 1
+						return nil, nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{
+				Concurrency:           tt.steps,
+				CompensateConcurrency: tt.compensateConcurrency,
+			})
+			m.ExecutedSteps = append(m.ExecutedSteps, m.Steps...)
+
+			if _, err := m.Compensate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.Context.State.Counter != tt.expectedCounter {
+				t.Errorf("expected counter %d, got %d", tt.expectedCounter, m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type raceModeTestCase struct {
+	name              string
+	delays            []time.Duration
+	expectedResult    string
+	expectedCancelled int32
+}
+
+func TestConcurrentStrategy_RaceMode(t *testing.T) {
+	tests := []raceModeTestCase{
+		{
+			name:              "FastestMirrorWins",
+			delays:            []time.Duration{time.Second, 5 * time.Millisecond, time.Second},
+			expectedResult:    "Mirror2",
+			expectedCancelled: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cancelled atomic.Int32
+			var steps []tango.Step[Services, State]
+			for i, delay := range tt.delays {
+				name := fmt.Sprintf("Mirror%d", i+1)
+				steps = append(steps, tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						select {
+						case <-time.After(delay):
+							return ctx.Machine.Done(name), nil
+						case <-ctx.Context().Done():
+							cancelled.Add(1)
+							return ctx.Machine.Next("Cancelled"), nil
+						}
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: len(steps), RaceMode: true})
+
+			start := time.Now()
+			response, err := m.Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != tt.expectedResult {
+				t.Errorf("expected result %v, got %v", tt.expectedResult, response.Result)
+			}
+			if cancelled.Load() != tt.expectedCancelled {
+				t.Errorf("expected %d cancelled steps, got %d", tt.expectedCancelled, cancelled.Load())
+			}
+			if elapsed := time.Since(start); elapsed >= time.Second {
+				t.Errorf("expected the race to end with the fastest step, took %v", elapsed)
+			}
+		})
+	}
+}
+
+type raceModeSchedulingTestCase struct {
+	name           string
+	steps          []string
+	keys           map[string]string
+	dependencies   map[string][]string
+	winner         string
+	expectedEvents []string
+}
+
+func TestConcurrentStrategy_RaceMode_Scheduling(t *testing.T) {
+	tests := []raceModeSchedulingTestCase{
+		{
+			name:           "KeyRunsOnce",
+			steps:          []string{"Mirror1", "Mirror2"},
+			keys:           map[string]string{"Mirror1": "mirror", "Mirror2": "mirror"},
+			winner:         "Mirror1",
+			expectedEvents: []string{"Mirror1 started", "Mirror1 finished"},
+		},
+		{
+			name:           "WaitsForDependency",
+			steps:          []string{"Fetch", "Lookup"},
+			dependencies:   map[string][]string{"Fetch": {"Lookup"}},
+			winner:         "Fetch",
+			expectedEvents: []string{"Lookup started", "Lookup finished", "Fetch started", "Fetch finished"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var events []string
+			record := func(event string) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, event)
+			}
+
+			steps := make([]tango.Step[Services, State], len(tt.steps))
+			for i, name := range tt.steps {
+				steps[i] = tango.Step[Services, State]{
+					Name:         name,
+					Key:          tt.keys[name],
+					MustRunAfter: tt.dependencies[name],
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						record(name + " started")
+						time.Sleep(10 * time.Millisecond)
+						record(name + " finished")
+						if name != tt.winner {
+							return ctx.Machine.Next(name), nil
+						}
+						return ctx.Machine.Done(name), nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: len(steps), RaceMode: true})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(events, tt.expectedEvents) {
+				t.Errorf("expected events %v, got %v", tt.expectedEvents, events)
+			}
+		})
+	}
+}
+
+type trackSkippedTestCase struct {
+	name                string
+	compensateSkipped   bool
+	expectedExecuted    []string
+	expectedSkipped     []string
+	expectedCompensated []string
+}
+
+func TestSequentialStrategy_TrackSkipped(t *testing.T) {
+	tests := []trackSkippedTestCase{
+		{
+			name:                "TrackOnly",
+			expectedExecuted:    []string{"Step1", "Step4", "Step6"},
+			expectedSkipped:     []string{"Step2", "Step3", "Step5"},
+			expectedCompensated: []string{"Step6", "Step4", "Step1"},
+		},
+		{
+			name:                "CompensateSkipped",
+			compensateSkipped:   true,
+			expectedExecuted:    []string{"Step1", "Step4", "Step6"},
+			expectedSkipped:     []string{"Step2", "Step3", "Step5"},
+			expectedCompensated: []string{"Step6", "Step4", "Step1", "Step5", "Step3", "Step2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compensated []string
+			responses := map[string]func(m *tango.Machine[Services, State]) *tango.Response[Services, State]{
+				"Step1": func(m *tango.Machine[Services, State]) *tango.Response[Services, State] {
+					return m.Jump("Jump", "Step4")
+				},
+				"Step4": func(m *tango.Machine[Services, State]) *tango.Response[Services, State] { return m.Skip("Skip", 1) },
+				"Step6": func(m *tango.Machine[Services, State]) *tango.Response[Services, State] { return m.Error("failed") },
+			}
+
+			var steps []tango.Step[Services, State]
+			for i := 1; i <= 6; i++ {
+				name := fmt.Sprintf("Step%d", i)
+				steps = append(steps, tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if respond, ok := responses[name]; ok {
+							return respond(ctx.Machine), nil
+						}
+						return ctx.Machine.Next(name), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, name)
+						return nil, nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				TrackSkipped:      true,
+				CompensateSkipped: tt.compensateSkipped,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatalf("expected an error")
+			}
+
+			var executed []string
+			for _, step := range m.ExecutedSteps {
+				executed = append(executed, step.Name)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+			if skipped := m.SkippedSteps(); !reflect.DeepEqual(skipped, tt.expectedSkipped) {
+				t.Errorf("expected skipped steps %v, got %v", tt.expectedSkipped, skipped)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+type mustRunAfterTestCase struct {
+	name          string
+	dependencies  map[string][]string
+	expectedError string
+}
+
+func TestConcurrentStrategy_MustRunAfter(t *testing.T) {
+	tests := []mustRunAfterTestCase{
+		{
+			name:         "WaitsForDependency",
+			dependencies: map[string][]string{"Ship": {"Charge"}},
+		},
+		{
+			name:          "UnknownDependency",
+			dependencies:  map[string][]string{"Ship": {"Pack"}},
+			expectedError: "step Ship must run after Pack, which is not scheduled",
+		},
+		{
+			name:          "Cycle",
+			dependencies:  map[string][]string{"Ship": {"Charge"}, "Charge": {"Ship"}},
+			expectedError: "steps [Ship, Charge] cannot be ordered: MustRunAfter forms a cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var finished []string
+			step := func(name string, delay time.Duration) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name:         name,
+					MustRunAfter: tt.dependencies[name],
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						time.Sleep(delay)
+						mu.Lock()
+						defer mu.Unlock()
+						finished = append(finished, name)
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Ship", 0),
+				step("Charge", 20*time.Millisecond),
+				step("Email", 0),
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 3})
+
+			_, err := m.Run()
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				if len(finished) != 0 {
+					t.Errorf("expected no step to run, got %v", finished)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			position := make(map[string]int)
+			for i, name := range finished {
+				position[name] = i
+			}
+			if len(finished) != 3 || position["Ship"] < position["Charge"] {
+				t.Errorf("expected Ship to finish after Charge, got %v", finished)
+			}
+			if position["Email"] > position["Charge"] {
+				t.Errorf("expected Email to run alongside Charge, got %v", finished)
+			}
+		})
+	}
+}
+
+func TestConcurrentStrategy_MustRunAfter_Barrier(t *testing.T) {
+	tests := []mustRunAfterTestCase{
+		{
+			name:         "EarlierBarrier",
+			dependencies: map[string][]string{"Charge": {"Reserved"}},
+		},
+		{
+			name:         "EarlierStep",
+			dependencies: map[string][]string{"Charge": {"Reserve"}},
+		},
+		{
+			name:          "LaterBarrier",
+			dependencies:  map[string][]string{"Reserve": {"Reserved"}},
+			expectedError: "step Reserve must run after barrier Reserved, which comes after it",
+		},
+		{
+			name:          "LaterStep",
+			dependencies:  map[string][]string{"Reserve": {"Charge"}},
+			expectedError: "step Reserve must run after Charge, which runs after a barrier that follows it",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var finished []string
+			step := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name:         name,
+					MustRunAfter: tt.dependencies[name],
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						mu.Lock()
+						defer mu.Unlock()
+						finished = append(finished, name)
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Reserve"),
+				tango.BarrierStep[Services, State]("Reserved"),
+				step("Charge"),
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 2})
+
+			_, err := m.Run()
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				if len(finished) != 0 {
+					t.Errorf("expected no step to run, got %v", finished)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(finished, []string{"Reserve", "Charge"}) {
+				t.Errorf("expected Reserve then Charge, got %v", finished)
+			}
+		})
+	}
+}
+
+type concurrentStateTestCase struct {
+	name            string
+	increments      int
+	mapInput        bool
+	isolate         bool
+	expectedCounter int
+}
+
+func TestConcurrentStrategy_State(t *testing.T) {
+	tests := []concurrentStateTestCase{
+		{
+			name:            "SharedContext",
+			increments:      3,
+			expectedCounter: 3,
+		},
+		{
+			name:            "MapInput",
+			increments:      1,
+			mapInput:        true,
+			expectedCounter: 1,
+		},
+		{
+			name:            "IsolatePreviousResult",
+			increments:      1,
+			isolate:         true,
+			expectedCounter: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The steps take turns so that each sees the State the previous one left.
+			turn := make(chan struct{}, 1)
+			turn <- struct{}{}
+			steps := make([]tango.Step[Services, State], 3)
+			for i := range steps {
+				increment := i < tt.increments
+				steps[i] = tango.Step[Services, State]{
+					Name: fmt.Sprintf("Step%d", i),
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						<-turn
+						if increment {
+							ctx.State.Counter++
+						}
+						turn <- struct{}{}
+						return ctx.Machine.Next(nil), nil
+					},
+				}
+				if tt.mapInput {
+					steps[i].MapInput = func(previous *tango.Response[Services, State]) any {
+						return previous
+					}
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				IsolatePreviousResult: tt.isolate,
+			}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 3})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.Context.State.Counter != tt.expectedCounter {
+				t.Errorf("expected counter %d, got %d", tt.expectedCounter, m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type duplicateNamesTestCase struct {
+	name         string
+	dependencies map[string][]string
+}
+
+func TestConcurrentStrategy_DuplicateNames(t *testing.T) {
+	tests := []duplicateNamesTestCase{
+		{
+			name: "NoDependencies",
+		},
+		{
+			name:         "WaitsForEveryNamedStep",
+			dependencies: map[string][]string{"Ship": {"Charge"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var finished []string
+			step := func(name string, delay time.Duration) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name:         name,
+					MustRunAfter: tt.dependencies[name],
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						time.Sleep(delay)
+						mu.Lock()
+						defer mu.Unlock()
+						finished = append(finished, name)
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Charge", 0),
+				step("Ship", 0),
+				step("Charge", 20*time.Millisecond),
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 3})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(finished) != 3 {
+				t.Fatalf("expected 3 steps to run, got %v", finished)
+			}
+			if tt.dependencies != nil && finished[2] != "Ship" {
+				t.Errorf("expected Ship to finish after both Charge steps, got %v", finished)
+			}
+		})
+	}
+}
+
+type dependencyErrorTestCase struct {
+	name                string
+	nonCritical         bool
+	expectedError       string
+	expectedRan         []string
+	expectedCompensated []string
+}
+
+func TestConcurrentStrategy_DependencyErrorResponse(t *testing.T) {
+	tests := []dependencyErrorTestCase{
+		{
+			name:                "DependentDoesNotRun",
+			expectedError:       "step Charge failed: declined",
+			expectedRan:         []string{"Charge"},
+			expectedCompensated: []string{"Charge"},
+		},
+		{
+			name:        "NonCriticalDependencyTolerated",
+			nonCritical: true,
+			expectedRan: []string{"Charge", "Ship"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var ran, compensated []string
+			record := func(list *[]string, name string) {
+				mu.Lock()
+				defer mu.Unlock()
+				*list = append(*list, name)
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name:        "Charge",
+					NonCritical: tt.nonCritical,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						record(&ran, "Charge")
+						return ctx.Machine.Error("declined"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						record(&compensated, "Charge")
+						return nil, nil
+					},
+				},
+				{
+					Name:         "Ship",
+					MustRunAfter: []string{"Charge"},
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						record(&ran, "Ship")
+						return ctx.Machine.Next(nil), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						record(&compensated, "Ship")
+						return nil, nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 2})
+
+			_, err := m.Run()
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ran, tt.expectedRan) {
+				t.Errorf("expected steps %v to run, got %v", tt.expectedRan, ran)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+var errOutOfStock = errors.New("out of stock")
+
+type jumpOnErrorTestCase struct {
+	name             string
+	err              error
+	asResponse       bool
+	clone            bool
+	expectedResult   string
+	expectedExecuted []string
+	expectCompensate bool
+}
+
+func TestSequentialStrategy_JumpOnError(t *testing.T) {
+	tests := []jumpOnErrorTestCase{
+		{
+			name:             "MatchingError",
+			err:              fmt.Errorf("charge: %w", errOutOfStock),
+			expectedResult:   "Backordered",
+			expectedExecuted: []string{"Reserve", "Backorder"},
+		},
+		{
+			name:             "MatchingErrorResponse",
+			err:              errOutOfStock,
+			asResponse:       true,
+			expectedResult:   "Backordered",
+			expectedExecuted: []string{"Reserve", "Charge", "Backorder"},
+		},
+		{
+			name:             "MatchingErrorOnClone",
+			err:              fmt.Errorf("charge: %w", errOutOfStock),
+			clone:            true,
+			expectedResult:   "Backordered",
+			expectedExecuted: []string{"Reserve", "Backorder"},
+		},
+		{
+			name:             "OtherError",
+			err:              errors.New("card declined"),
+			asResponse:       true,
+			expectedExecuted: []string{"Reserve", "Charge"},
+			expectCompensate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compensated := false
+			var recovered interface{}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Reserve",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Reserved"), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = true
+						return nil, nil
+					},
+				},
+				{
+					Name: "Charge",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if tt.asResponse {
+							return ctx.Machine.Error(tt.err), nil
+						}
+						return nil, tt.err
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return nil, nil
+					},
+				},
+				{
+					Name: "Ship",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Shipped"), nil
+					},
+				},
+				{
+					Name: "Backorder",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						recovered = ctx.PreviousResult.Result
+						return ctx.Machine.Done("Backordered"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			m.JumpOnError("Backorder", func(err error) bool {
+				return errors.Is(err, errOutOfStock)
+			})
+			if tt.clone {
+				m = m.Clone()
+			}
+
+			response, err := m.Run()
+
+			var executed []string
+			for _, step := range m.ExecutedSteps {
+				executed = append(executed, step.Name)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+			if compensated != tt.expectCompensate {
+				t.Errorf("expected compensated to be %v", tt.expectCompensate)
+			}
+			if tt.expectCompensate {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != tt.expectedResult {
+				t.Errorf("expected result %v, got %v", tt.expectedResult, response.Result)
+			}
+			if recoveredErr, ok := recovered.(error); !ok || !errors.Is(recoveredErr, errOutOfStock) {
+				t.Errorf("expected the recovery step to see the error, got %v", recovered)
+			}
+		})
+	}
+}
+
+type barrierTestCase struct {
+	name     string
+	strategy tango.ExecutionStrategy[Services, State]
+}
+
+func TestBarrierStep(t *testing.T) {
+	tests := []barrierTestCase{
+		{
+			name:     "Concurrent",
+			strategy: &tango.ConcurrentStrategy[Services, State]{Concurrency: 4},
+		},
+		{
+			name:     "Sequential",
+			strategy: &tango.SequentialStrategy[Services, State]{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var phaseADone atomic.Int32
+			var violations atomic.Int32
+
+			phaseA := func(name string, delay time.Duration) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						time.Sleep(delay)
+						phaseADone.Add(1)
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+			phaseB := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if phaseADone.Load() != 2 {
+							violations.Add(1)
+						}
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				phaseA("A1", 10*time.Millisecond),
+				phaseA("A2", 20*time.Millisecond),
+				tango.BarrierStep[Services, State]("Barrier"),
+				phaseB("B1"),
+				phaseB("B2"),
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, tt.strategy)
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if violations.Load() != 0 {
+				t.Errorf("expected phase B to start after phase A, got %d early starts", violations.Load())
+			}
+			if counts := m.StepExecutionCounts(); counts["B1"] != 1 || counts["B2"] != 1 || counts["Barrier"] != 0 {
+				t.Errorf("expected both phase B steps and no barrier execution, got %v", counts)
+			}
+		})
+	}
+}
+
+const again tango.ResponseStatus = "AGAIN"
+
+type statusHandlersTestCase struct {
+	name               string
+	handlers           map[tango.ResponseStatus]tango.StatusHandler[Services, State]
+	expectedExecutions int
+	expectedError      string
+}
+
+func TestSequentialStrategy_StatusHandlers(t *testing.T) {
+	tests := []statusHandlersTestCase{
+		{
+			name: "CustomStatusLoops",
+			handlers: map[tango.ResponseStatus]tango.StatusHandler[Services, State]{
+				again: func(m *tango.Machine[Services, State], response *tango.Response[Services, State], index int) tango.StatusControl[Services, State] {
+					return tango.StatusControl[Services, State]{Next: index}
+				},
+			},
+			expectedExecutions: 3,
+		},
+		{
+			name:               "UnknownStatus",
+			expectedExecutions: 1,
+			expectedError:      "step Poll returned unknown status AGAIN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Poll",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						ctx.State.Counter++
+						if ctx.State.Counter < 3 {
+							return &tango.Response[Services, State]{Status: again}, nil
+						}
+						return ctx.Machine.Next(ctx.State.Counter), nil
+					},
+				},
+				{
+					Name: "Finish",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(ctx.PreviousResult.Result), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				StatusHandlers: tt.handlers,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if counts := m.StepExecutionCounts(); counts["Poll"] != tt.expectedExecutions {
+				t.Errorf("expected Poll to run %d times, got %d", tt.expectedExecutions, counts["Poll"])
+			}
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != 3 {
+				t.Errorf("expected result 3, got %v", response.Result)
+			}
+		})
+	}
+}
+
+type verifyCompensationTestCase struct {
+	name           string
+	steps          []tango.Step[Services, State]
+	expectedErrors []string
+}
+
+func TestMachine_VerifyCompensation(t *testing.T) {
+	execute := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+		return ctx.Machine.Next("Next"), nil
+	}
+	compensate := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+		return nil, nil
+	}
+
+	tests := []verifyCompensationTestCase{
+		{
+			name: "AllCompensable",
+			steps: []tango.Step[Services, State]{
+				{Name: "Reserve", Execute: execute, Compensate: compensate},
+				tango.BarrierStep[Services, State]("Barrier"),
+				{Name: "Charge", Execute: execute, Compensate: compensate},
+			},
+		},
+		{
+			name: "MissingCompensate",
+			steps: []tango.Step[Services, State]{
+				{Name: "Reserve", Execute: execute, Compensate: compensate},
+				{Name: "Charge", Execute: execute},
+				{Name: "Ship", Execute: execute, Compensate: compensate, Fallback: &tango.Step[Services, State]{Name: "Courier", Execute: execute}},
+			},
+			expectedErrors: []string{"step Charge has no compensate function", "step Courier has no compensate function"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", tt.steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			var messages []string
+			for _, err := range m.VerifyCompensation() {
+				messages = append(messages, err.Error())
+			}
+			if !reflect.DeepEqual(messages, tt.expectedErrors) {
+				t.Errorf("expected errors %v, got %v", tt.expectedErrors, messages)
+			}
+			if len(m.ExecutedSteps) != 0 {
+				t.Errorf("expected no step to be executed")
+			}
+		})
+	}
+}
+
+type isolatePreviousResultTestCase struct {
+	name     string
+	previous *tango.Response[Services, State]
+	seed     bool
+	expected interface{}
+}
+
+func TestConcurrentStrategy_IsolatePreviousResult(t *testing.T) {
+	tests := []isolatePreviousResultTestCase{
+		{
+			name:     "ContextResult",
+			previous: &tango.Response[Services, State]{Result: "input", Status: tango.NEXT},
+			expected: "input",
+		},
+		{
+			name:     "ResultBeforeBarrier",
+			seed:     true,
+			expected: "seed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			seen := make(map[string]interface{})
+			worker := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						var result interface{}
+						if ctx.PreviousResult != nil {
+							result = ctx.PreviousResult.Result
+						}
+						mu.Lock()
+						seen[name] = result
+						mu.Unlock()
+						time.Sleep(time.Millisecond)
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+
+			var steps []tango.Step[Services, State]
+			if tt.seed {
+				steps = append(steps,
+					tango.Step[Services, State]{
+						Name: "Seed",
+						Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+							return ctx.Machine.Next("seed"), nil
+						},
+					},
+					tango.BarrierStep[Services, State]("Barrier"),
+				)
+			}
+			steps = append(steps, worker("A"), worker("B"), worker("C"), worker("D"))
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{PreviousResult: tt.previous}, &tango.MachineConfig[Services, State]{IsolatePreviousResult: true}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 2})
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, name := range []string{"A", "B", "C", "D"} {
+				if seen[name] != tt.expected {
+					t.Errorf("expected step %s to see %v, got %v", name, tt.expected, seen[name])
+				}
+			}
+		})
+	}
+}
+
+type restartTestCase struct {
+	name                string
+	restarts            int
+	maxRestarts         int
+	expectedExecuted    []string
+	expectedCompensated []string
+	expectedError       string
+}
+
+func TestSequentialStrategy_Restart(t *testing.T) {
+	tests := []restartTestCase{
+		{
+			name:             "RestartOnce",
+			restarts:         1,
+			expectedExecuted: []string{"Prepare", "Attempt", "Finish"},
+		},
+		{
+			name:                "TooManyRestarts",
+			restarts:            3,
+			maxRestarts:         2,
+			expectedCompensated: []string{"Prepare"},
+			expectedError:       "step Attempt restarted the machine more than 2 times",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			var compensated []string
+			compensate := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					compensated = append(compensated, name)
+					return nil, nil
+				}
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Prepare",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(nil), nil
+					},
+					Compensate: compensate("Prepare"),
+				},
+				{
+					Name: "Attempt",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						attempts++
+						if attempts <= tt.restarts {
+							return ctx.Machine.Restart(attempts), nil
+						}
+						return ctx.Machine.Next(attempts), nil
+					},
+					Compensate: compensate("Attempt"),
+				},
+				{
+					Name: "Finish",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(ctx.PreviousResult.Result), nil
+					},
+					Compensate: compensate("Finish"),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				MaxRestarts: tt.maxRestarts,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if response.Result != tt.restarts+1 {
+					t.Errorf("expected result %d, got %v", tt.restarts+1, response.Result)
+				}
+				var executed []string
+				for _, step := range m.ExecutedSteps {
+					executed = append(executed, step.Name)
+				}
+				if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+					t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+				}
+				if count := m.StepExecutionCounts()["Prepare"]; count != tt.restarts+1 {
+					t.Errorf("expected Prepare to run %d times, got %d", tt.restarts+1, count)
+				}
+			}
+
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+type errorBudgetTestCase struct {
+	name                string
+	failing             map[string]bool
+	expectedExecuted    []string
+	expectedCompensated []string
+	expectedError       string
+}
+
+func TestSequentialStrategy_ErrorBudget(t *testing.T) {
+	tests := []errorBudgetTestCase{
+		{
+			name:             "WithinBudget",
+			failing:          map[string]bool{"Email": true, "Sms": true},
+			expectedExecuted: []string{"Reserve", "Email", "Sms", "Push", "Ship"},
+		},
+		{
+			name:                "BudgetExhausted",
+			failing:             map[string]bool{"Email": true, "Sms": true, "Push": true},
+			expectedExecuted:    []string{"Reserve", "Email", "Sms", "Push"},
+			expectedCompensated: []string{"Push", "Sms", "Email", "Reserve"},
+			expectedError:       "error budget of 2 exhausted: step Push failed: Push unavailable",
+		},
+		{
+			name:                "CriticalFailureNotBudgeted",
+			failing:             map[string]bool{"Email": true, "Ship": true},
+			expectedExecuted:    []string{"Reserve", "Email", "Sms", "Push", "Ship"},
+			expectedCompensated: []string{"Ship", "Push", "Sms", "Email", "Reserve"},
+			expectedError:       "step Ship failed: Ship unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed, compensated []string
+			var steps []tango.Step[Services, State]
+			for _, name := range []string{"Reserve", "Email", "Sms", "Push", "Ship"} {
+				steps = append(steps, tango.Step[Services, State]{
+					Name:        name,
+					NonCritical: name != "Reserve" && name != "Ship",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executed = append(executed, name)
+						if tt.failing[name] {
+							return ctx.Machine.Error(name + " unavailable"), nil
+						}
+						return ctx.Machine.Next(nil), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, name)
+						return nil, nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				ErrorBudget: 2,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			_, err := m.Run()
+			if tt.expectedError == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedError != "" && (err == nil || err.Error() != tt.expectedError) {
+				t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+			if !reflect.DeepEqual(compensated, tt.expectedCompensated) {
+				t.Errorf("expected compensated steps %v, got %v", tt.expectedCompensated, compensated)
+			}
+		})
+	}
+}
+
+type dependencyCompensationTestCase struct {
+	name        string
+	concurrency int
+	// before lists pairs of steps where the first must be compensated before the second.
+	before [][2]string
+}
+
+func TestConcurrentStrategy_CompensateDependencies(t *testing.T) {
+	tests := []dependencyCompensationTestCase{
+		{
+			name:        "Diamond",
+			concurrency: 4,
+			before:      [][2]string{{"Join", "Left"}, {"Join", "Right"}, {"Left", "Root"}, {"Right", "Root"}},
+		},
+		{
+			name:        "DiamondLimited",
+			concurrency: 2,
+			before:      [][2]string{{"Join", "Left"}, {"Join", "Right"}, {"Left", "Root"}, {"Right", "Root"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var compensated []string
+			step := func(name string, dependencies ...string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name:         name,
+					MustRunAfter: dependencies,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(name), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if name == "Join" || name == "Left" {
+							time.Sleep(10 * time.Millisecond)
+						}
+						mu.Lock()
+						defer mu.Unlock()
+						compensated = append(compensated, name)
+						return nil, nil
+					},
+				}
+			}
+
+			fail := step("Fail", "Join")
+			fail.Execute = func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return nil, errors.New("downstream unavailable")
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Root"),
+				step("Left", "Root"),
+				step("Right", "Root"),
+				step("Join", "Left", "Right"),
+				fail,
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{
+				Concurrency:           tt.concurrency,
+				CompensateConcurrency: tt.concurrency,
+			})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected the run to fail")
+			}
+
+			position := make(map[string]int)
+			for i, name := range compensated {
+				position[name] = i
+			}
+			if len(position) != 4 {
+				t.Fatalf("expected 4 compensated steps, got %v", compensated)
+			}
+			for _, pair := range tt.before {
+				if position[pair[0]] > position[pair[1]] {
+					t.Errorf("expected %s to be compensated before %s, got %v", pair[0], pair[1], compensated)
+				}
+			}
+		})
+	}
+}
+
+type compensateConcurrencyTestCase struct {
+	name                  string
+	compensateConcurrency int
+	compensateTimeout     time.Duration
+	expectedMaxInFlight   int
+}
+
+func TestConcurrentStrategy_CompensateConcurrency(t *testing.T) {
+	tests := []compensateConcurrencyTestCase{
+		{
+			name:                "SequentialByDefault",
+			expectedMaxInFlight: 1,
+		},
+		{
+			name:                  "IndependentStepsInParallel",
+			compensateConcurrency: 4,
+			expectedMaxInFlight:   2,
+		},
+		{
+			name:                "SequentialByDefaultWithTimeout",
+			compensateTimeout:   time.Second,
+			expectedMaxInFlight: 1,
+		},
+		{
+			name:                  "TimeoutsRunOneAtATime",
+			compensateConcurrency: 4,
+			compensateTimeout:     time.Second,
+			expectedMaxInFlight:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var compensated []string
+			inFlight, maxInFlight := 0, 0
+			step := func(name string, dependencies ...string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name:         name,
+					MustRunAfter: dependencies,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(name), nil
+					},
+					CompensateTimeout: tt.compensateTimeout,
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						mu.Lock()
+						inFlight++
+						maxInFlight = max(maxInFlight, inFlight)
+						mu.Unlock()
+						time.Sleep(20 * time.Millisecond)
+						mu.Lock()
+						defer mu.Unlock()
+						inFlight--
+						compensated = append(compensated, name)
+						return nil, nil
+					},
+				}
+			}
+
+			fail := step("Fail", "Ship", "Invoice")
+			fail.Execute = func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return nil, errors.New("downstream unavailable")
+			}
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				step("Reserve"),
+				step("Invoice"),
+				step("Ship", "Reserve"),
+				fail,
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{
+				Concurrency:           4,
+				CompensateConcurrency: tt.compensateConcurrency,
+			})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected the run to fail")
+			}
+
+			if maxInFlight != tt.expectedMaxInFlight {
+				t.Errorf("expected at most %d compensations at once, got %d", tt.expectedMaxInFlight, maxInFlight)
+			}
+			position := make(map[string]int)
+			for i, name := range compensated {
+				position[name] = i
+			}
+			if len(position) != 3 {
+				t.Fatalf("expected 3 compensated steps, got %v", compensated)
+			}
+			if position["Ship"] > position["Reserve"] {
+				t.Errorf("expected Ship to be compensated before Reserve, got %v", compensated)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+)
+
+// Semaphore limits how many steps execute at once. A Semaphore set on the configuration of
+// several machines caps the steps executing across all of them, for example to protect a
+// shared database. A step gives up its slot while it waits out a retry backoff or runs a
+// nested machine, so a nested machine sharing the semaphore can still execute its steps.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates a semaphore that lets up to size steps execute at once.
+func NewSemaphore(size int) *Semaphore {
+	return &Semaphore{slots: make(chan struct{}, size)}
+}
+
+// Acquire waits for a free slot, returning ctx's error if ctx ends first.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (s *Semaphore) Release() {
+	<-s.slots
+}
+
+// slot is a Semaphore slot taken by an executing step.
+type slot struct {
+	semaphore *Semaphore
+	held      bool
+}
+
+// acquire takes the slot, returning ctx's error if ctx ends first.
+func (s *slot) acquire(ctx context.Context) error {
+	if err := s.semaphore.Acquire(ctx); err != nil {
+		return err
+	}
+	s.held = true
+	return nil
+}
+
+// release frees the slot if it is held.
+func (s *slot) release() {
+	if s.held {
+		s.held = false
+		s.semaphore.Release()
+	}
+}
+
+// yield frees the slot while fn runs and takes it back afterwards. A nil slot runs fn as is.
+func (s *slot) yield(ctx context.Context, fn func() error) error {
+	if s == nil {
+		return fn()
+	}
+	s.release()
+	err := fn()
+	if acquireErr := s.acquire(ctx); acquireErr != nil && err == nil {
+		return fmt.Errorf("waiting for semaphore: %w", acquireErr)
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package tango_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/phr3nzy/tango"
+)
+
+type semaphoreTestCase struct {
+	name              string
+	size              int
+	machines          int
+	expectedMaxActive int32
+}
+
+func TestSemaphore(t *testing.T) {
+	tests := []semaphoreTestCase{
+		{
+			name:              "SharedBySize1",
+			size:              1,
+			machines:          2,
+			expectedMaxActive: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			semaphore := tango.NewSemaphore(tt.size)
+			var active, maxActive atomic.Int32
+
+			step := func(name string) tango.Step[Services, State] {
+				return tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						current := active.Add(1)
+						defer active.Add(-1)
+						for {
+							seen := maxActive.Load()
+							if current <= seen || maxActive.CompareAndSwap(seen, current) {
+								break
+							}
+						}
+						time.Sleep(2 * time.Millisecond)
+						return ctx.Machine.Next(name), nil
+					},
+				}
+			}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tt.machines)
+			for i := 0; i < tt.machines; i++ {
+				m := tango.NewMachine(fmt.Sprintf("Machine%d", i), []tango.Step[Services, State]{
+					step("Step1"), step("Step2"), step("Step3"),
+				}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+					Semaphore: semaphore,
+				}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 3})
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := m.Run(); err != nil {
+						errs <- err
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if maxActive.Load() != tt.expectedMaxActive {
+				t.Errorf("expected at most %d steps executing at once, got %d", tt.expectedMaxActive, maxActive.Load())
+			}
+		})
+	}
+}
+
+type semaphoreYieldTestCase struct {
+	name   string
+	nested bool
+}
+
+func TestSemaphore_YieldsSlot(t *testing.T) {
+	tests := []semaphoreYieldTestCase{
+		{
+			name:   "NestedMachine",
+			nested: true,
+		},
+		{
+			name: "RetryBackoff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			semaphore := tango.NewSemaphore(1)
+			config := func() *tango.MachineConfig[Services, State] {
+				return &tango.MachineConfig[Services, State]{Semaphore: semaphore}
+			}
+			var otherDone atomic.Bool
+			other := tango.NewMachine("Other", []tango.Step[Services, State]{
+				{
+					Name: "Other",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						otherDone.Store(true)
+						return ctx.Machine.Next(nil), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, config(), &tango.SequentialStrategy[Services, State]{})
+
+			attempts := 0
+			otherErr := make(chan error, 1)
+			step := tango.Step[Services, State]{
+				Name: "Parent",
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					if tt.nested {
+						return &tango.Response[Services, State]{Status: tango.NEXT, NewMachine: other}, nil
+					}
+					attempts++
+					if attempts == 1 {
+						go func() {
+							_, err := other.Run()
+							otherErr <- err
+						}()
+						return ctx.Machine.Error("busy"), nil
+					}
+					if !otherDone.Load() {
+						return nil, errors.New("the other machine did not run during the backoff")
+					}
+					return ctx.Machine.Next(nil), nil
+				},
+				Retry: &tango.RetryPolicy{MaxAttempts: 2, Backoff: 100 * time.Millisecond},
+			}
+			m := tango.NewMachine("Parent", []tango.Step[Services, State]{step},
+				&tango.MachineContext[Services, State]{}, config(), &tango.SequentialStrategy[Services, State]{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if _, err := m.RunContext(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.nested {
+				if err := <-otherErr; err != nil {
+					t.Fatalf("unexpected error from the other machine: %v", err)
+				}
+			}
+			if !otherDone.Load() {
+				t.Error("expected the other machine to run")
+			}
+		})
+	}
+}
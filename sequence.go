@@ -0,0 +1,24 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+)
+
+// Sequence runs fns one after the other as the steps of a sequential machine named
+// "sequence" and returns the outcome. The steps are named "step-1", "step-2" and so on, in
+// order. A nil config runs with the default configuration.
+func Sequence[Services, State any](
+	ctx *MachineContext[Services, State],
+	config *MachineConfig[Services, State],
+	fns ...func(ctx *MachineContext[Services, State]) (*Response[Services, State], error),
+) RunOutcome[Services, State] {
+	if config == nil {
+		config = &MachineConfig[Services, State]{}
+	}
+	m := NewMachine("sequence", nil, ctx, config, &SequentialStrategy[Services, State]{})
+	for i, fn := range fns {
+		m.AddStep(Step[Services, State]{Name: fmt.Sprintf("step-%d", i+1), Execute: fn})
+	}
+	return m.RunWithOutcome(context.Background())
+}
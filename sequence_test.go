@@ -0,0 +1,68 @@
+package tango_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type sequenceTestCase struct {
+	name              string
+	fail              bool
+	expectedCompleted []string
+	expectedResult    interface{}
+}
+
+func TestSequence(t *testing.T) {
+	tests := []sequenceTestCase{
+		{
+			name:              "ThreeFunctions",
+			expectedCompleted: []string{"step-1", "step-2", "step-3"},
+			expectedResult:    6,
+		},
+		{
+			name:              "Failure",
+			fail:              true,
+			expectedCompleted: []string{"step-1", "step-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := tango.Sequence(&tango.MachineContext[Services, State]{}, nil,
+				func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					ctx.State.Counter = 1
+					return ctx.Machine.Next(nil), nil
+				},
+				func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					ctx.State.Counter *= 2
+					if tt.fail {
+						return ctx.Machine.Error("failed"), nil
+					}
+					return ctx.Machine.Next(nil), nil
+				},
+				func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					ctx.State.Counter *= 3
+					return ctx.Machine.Done(ctx.State.Counter), nil
+				},
+			)
+
+			if tt.fail {
+				if outcome.Err == nil {
+					t.Fatal("expected the sequence to fail")
+				}
+			} else {
+				if outcome.Err != nil {
+					t.Fatalf("unexpected error: %v", outcome.Err)
+				}
+				if outcome.Response.Result != tt.expectedResult {
+					t.Errorf("expected result %v, got %v", tt.expectedResult, outcome.Response.Result)
+				}
+			}
+			if !reflect.DeepEqual(outcome.CompletedSteps, tt.expectedCompleted) {
+				t.Errorf("expected completed steps %v, got %v", tt.expectedCompleted, outcome.CompletedSteps)
+			}
+		})
+	}
+}
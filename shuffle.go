@@ -0,0 +1,65 @@
+package tango
+
+import "math/rand"
+
+// ShuffleStrategy runs steps one at a time like SequentialStrategy, but in a random order, to
+// surface hidden ordering dependencies between steps meant to be independent. Steps are only
+// shuffled between barriers, and steps contributed by plugins keep their place. A failed run
+// compensates in the reverse of the order the steps actually ran in.
+type ShuffleStrategy[Services, State any] struct {
+	// Rand, when set, is the source used to shuffle the steps, so that an order that broke a
+	// run can be reproduced from its seed. By default the math/rand global source is used.
+	Rand *rand.Rand
+}
+
+// Clone returns a ShuffleStrategy with a Rand of its own, seeded from s.Rand when it is set, so
+// that clones shuffle independently and still reproducibly.
+func (s *ShuffleStrategy[Services, State]) Clone() ExecutionStrategy[Services, State] {
+	if s.Rand == nil {
+		return &ShuffleStrategy[Services, State]{}
+	}
+	return &ShuffleStrategy[Services, State]{Rand: rand.New(rand.NewSource(s.Rand.Int63()))}
+}
+
+func (s *ShuffleStrategy[Services, State]) Execute(m *Machine[Services, State]) (*Response[Services, State], error) {
+	steps := m.Steps
+	end := len(steps) - m.appended
+
+	shuffled := make([]Step[Services, State], len(steps))
+	copy(shuffled, steps)
+	segment := max(m.start, m.prepended)
+	for i := segment; i <= end; i++ {
+		if i == end || shuffled[i].Barrier {
+			s.shuffle(shuffled[segment:i])
+			segment = i + 1
+		}
+	}
+
+	m.Steps = shuffled
+	defer func() {
+		// Keep steps added during the run, which are inserted before the appended steps.
+		added := m.Steps[end : len(m.Steps)-m.appended]
+		restored := make([]Step[Services, State], 0, len(steps)+len(added))
+		restored = append(restored, steps[:end]...)
+		restored = append(restored, added...)
+		m.Steps = append(restored, steps[end:]...)
+	}()
+
+	return (&SequentialStrategy[Services, State]{}).Execute(m)
+}
+
+// shuffle randomizes the order of steps in place.
+func (s *ShuffleStrategy[Services, State]) shuffle(steps []Step[Services, State]) {
+	swap := func(i, j int) { steps[i], steps[j] = steps[j], steps[i] }
+	if s.Rand != nil {
+		s.Rand.Shuffle(len(steps), swap)
+		return
+	}
+	rand.Shuffle(len(steps), swap)
+}
+
+// Compensate compensates the executed steps like SequentialStrategy, most recently executed
+// first.
+func (s *ShuffleStrategy[Services, State]) Compensate(m *Machine[Services, State]) (*Response[Services, State], error) {
+	return (&SequentialStrategy[Services, State]{}).Compensate(m)
+}
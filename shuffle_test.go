@@ -0,0 +1,87 @@
+package tango_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type shuffleTestCase struct {
+	name string
+	seed int64
+	fail string
+}
+
+func TestShuffleStrategy(t *testing.T) {
+	tests := []shuffleTestCase{
+		{name: "Seed1", seed: 1},
+		{name: "Seed2", seed: 2},
+		{name: "CompensateInExecutionOrder", seed: 3, fail: "Step5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed, compensated []string
+			var steps []tango.Step[Services, State]
+			var names []string
+			for i := 0; i < 10; i++ {
+				name := fmt.Sprintf("Step%d", i)
+				names = append(names, name)
+				steps = append(steps, tango.Step[Services, State]{
+					Name: name,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executed = append(executed, name)
+						if name == tt.fail {
+							return ctx.Machine.Error("failed"), nil
+						}
+						return ctx.Machine.Next(nil), nil
+					},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						compensated = append(compensated, name)
+						return nil, nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ShuffleStrategy[Services, State]{Rand: rand.New(rand.NewSource(tt.seed))})
+			_, err := m.Run()
+
+			if tt.fail != "" {
+				if err == nil {
+					t.Fatal("expected the run to fail")
+				}
+				var reversed []string
+				for i := len(executed) - 1; i >= 0; i-- {
+					reversed = append(reversed, executed[i])
+				}
+				if !reflect.DeepEqual(compensated, reversed) {
+					t.Errorf("expected compensation order %v, got %v", reversed, compensated)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reflect.DeepEqual(executed, names) {
+				t.Errorf("expected a shuffled order, got %v", executed)
+			}
+			sorted := append([]string(nil), executed...)
+			sort.Strings(sorted)
+			if !reflect.DeepEqual(sorted, names) {
+				t.Errorf("expected every step to run exactly once, got %v", executed)
+			}
+			var defined []string
+			for _, step := range m.Steps {
+				defined = append(defined, step.Name)
+			}
+			if !reflect.DeepEqual(defined, names) {
+				t.Errorf("expected the machine's steps to keep their order, got %v", defined)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package tango
+
+import "fmt"
+
+// Simulate runs the machine's control flow without side effects: each step's Execute function
+// is replaced by one returning a copy of the step's mock response, and nothing else the step or
+// the machine's configuration defines runs, so no hooks, plugins, retries or compensations. A
+// step without a mock returns NEXT with a nil result. The run is sequential, so mocked JUMP,
+// SKIP, DONE and ERROR responses steer it as they would a SequentialStrategy run.
+//
+// Simulate returns the names of the executed steps in order, and the error the run failed
+// with, if any. It returns an error without running if a mock names a step the machine does
+// not have. The machine itself is left untouched.
+func (m *Machine[Services, State]) Simulate(mocks map[string]*Response[Services, State]) ([]string, error) {
+	names := make(map[string]bool, len(m.Steps))
+	for _, step := range m.Steps {
+		names[step.Name] = true
+	}
+	for name := range mocks {
+		if !names[name] {
+			return nil, fmt.Errorf("mock for unknown step %s", name)
+		}
+	}
+
+	var executed []string
+	steps := make([]Step[Services, State], 0, len(m.Steps))
+	for _, step := range m.Steps {
+		mock := mocks[step.Name]
+		steps = append(steps, Step[Services, State]{
+			Name:    step.Name,
+			Barrier: step.Barrier,
+			Execute: func(ctx *MachineContext[Services, State]) (*Response[Services, State], error) {
+				executed = append(executed, step.Name)
+				if mock == nil {
+					return Next[any, Services, State](nil), nil
+				}
+				response := *mock
+				return &response, nil
+			},
+			Compensate: func(ctx *MachineContext[Services, State]) (*Response[Services, State], error) {
+				return nil, nil
+			},
+		})
+	}
+
+	config := &MachineConfig[Services, State]{}
+	if m.Config != nil {
+		config.MaxRequeues = m.Config.MaxRequeues
+		config.MaxRestarts = m.Config.MaxRestarts
+	}
+	initialContext := *m.InitialContext
+	initialContext.PreviousResult = nil
+	initialContext.ctx = nil
+	simulation := NewMachine(m.Name, steps, &initialContext, config, &SequentialStrategy[Services, State]{})
+	_, err := simulation.Run()
+	return executed, err
+}
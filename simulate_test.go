@@ -0,0 +1,86 @@
+package tango_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type simulateTestCase struct {
+	name             string
+	mocks            map[string]*tango.Response[Services, State]
+	expectedExecuted []string
+	expectErr        bool
+}
+
+func TestMachine_Simulate(t *testing.T) {
+	tests := []simulateTestCase{
+		{
+			name:             "NoMocks",
+			expectedExecuted: []string{"Validate", "Reserve", "Charge", "Notify", "Ship"},
+		},
+		{
+			name: "JumpsAndSkips",
+			mocks: map[string]*tango.Response[Services, State]{
+				"Validate": tango.Jump[any, Services, State](nil, "Charge"),
+				"Charge":   tango.Skip[any, Services, State](nil, 1),
+			},
+			expectedExecuted: []string{"Validate", "Charge", "Ship"},
+		},
+		{
+			name: "Done",
+			mocks: map[string]*tango.Response[Services, State]{
+				"Reserve": tango.Done[any, Services, State]("already reserved"),
+			},
+			expectedExecuted: []string{"Validate", "Reserve"},
+		},
+		{
+			name: "Error",
+			mocks: map[string]*tango.Response[Services, State]{
+				"Validate": tango.Jump[any, Services, State](nil, "Notify"),
+				"Notify":   tango.Error[any, Services, State](errors.New("mail server down")),
+			},
+			expectedExecuted: []string{"Validate", "Notify"},
+			expectErr:        true,
+		},
+		{
+			name: "UnknownStep",
+			mocks: map[string]*tango.Response[Services, State]{
+				"Refund": tango.Next[any, Services, State](nil),
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sideEffects []string
+			execute := func(name string) func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					sideEffects = append(sideEffects, name)
+					return ctx.Machine.Next(nil), nil
+				}
+			}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{Name: "Validate", Execute: execute("Validate")},
+				{Name: "Reserve", Execute: execute("Reserve")},
+				{Name: "Charge", Execute: execute("Charge")},
+				{Name: "Notify", Execute: execute("Notify")},
+				{Name: "Ship", Execute: execute("Ship")},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			executed, err := m.Simulate(tt.mocks)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+			if sideEffects != nil {
+				t.Errorf("expected no step to run, got %v", sideEffects)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package tango
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MachineSpec is the JSON description of a machine definition produced by ExportSpec.
+type MachineSpec struct {
+	Name     string     `json:"name"`
+	Strategy string     `json:"strategy,omitempty"`
+	Steps    []StepSpec `json:"steps"`
+}
+
+// StepSpec describes one step of a MachineSpec. Function fields are left out.
+type StepSpec struct {
+	Name              string         `json:"name"`
+	HasCompensate     bool           `json:"has_compensate"`
+	Key               string         `json:"key,omitempty"`
+	FeatureFlag       string         `json:"feature_flag,omitempty"`
+	Fallback          string         `json:"fallback,omitempty"`
+	Fallbacks         []string       `json:"fallbacks,omitempty"`
+	Retry             *RetrySpec     `json:"retry,omitempty"`
+	CompensateTimeout time.Duration  `json:"compensate_timeout,omitempty"`
+	NonCritical       bool           `json:"non_critical,omitempty"`
+	Metadata          map[string]any `json:"metadata,omitempty"`
+	InputType         string         `json:"input_type,omitempty"`
+	OutputType        string         `json:"output_type,omitempty"`
+}
+
+// RetrySpec describes the retry policy of a step.
+type RetrySpec struct {
+	MaxAttempts int           `json:"max_attempts"`
+	Backoff     time.Duration `json:"backoff"`
+}
+
+// MachineInfo describes a machine's configuration for programmatic introspection.
+type MachineInfo struct {
+	Name     string
+	Strategy string
+	Steps    []StepSpec
+	// Plugins lists the names of the configured plugins in the order they run.
+	Plugins []string
+	// Tags lists the machine's MachineConfig.Tags.
+	Tags []string
+}
+
+// Describe returns a description of the machine. It does not modify the machine.
+func (m *Machine[Services, State]) Describe() MachineInfo {
+	info := MachineInfo{
+		Name:     m.Name,
+		Strategy: strategyName(m.Strategy),
+		Steps:    make([]StepSpec, 0, len(m.Steps)),
+	}
+	for _, step := range m.Steps {
+		stepSpec := StepSpec{
+			Name:              step.Name,
+			HasCompensate:     m.compensateFunc(step) != nil,
+			Key:               step.Key,
+			FeatureFlag:       step.FeatureFlag,
+			Metadata:          step.Metadata,
+			CompensateTimeout: step.CompensateTimeout,
+			NonCritical:       step.NonCritical,
+			InputType:         step.InputType,
+			OutputType:        step.OutputType,
+		}
+		if step.Fallback != nil {
+			stepSpec.Fallback = step.Fallback.Name
+		}
+		for _, fallback := range step.Fallbacks {
+			if fallback != nil {
+				stepSpec.Fallbacks = append(stepSpec.Fallbacks, fallback.Name)
+			}
+		}
+		if step.Retry != nil {
+			stepSpec.Retry = &RetrySpec{MaxAttempts: step.Retry.MaxAttempts, Backoff: step.Retry.Backoff}
+		}
+		info.Steps = append(info.Steps, stepSpec)
+	}
+	if m.Config != nil {
+		for _, plugin := range sortPlugins(m.Config.Plugins) {
+			info.Plugins = append(info.Plugins, plugin.Name)
+		}
+		info.Tags = m.Config.Tags
+	}
+	return info
+}
+
+// ExportSpec returns a JSON document describing the machine's steps and strategy.
+func (m *Machine[Services, State]) ExportSpec() ([]byte, error) {
+	info := m.Describe()
+	spec := MachineSpec{
+		Name:     info.Name,
+		Strategy: info.Strategy,
+		Steps:    info.Steps,
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+// strategyName returns the type name of a strategy without its type parameters.
+func strategyName[Services, State any](strategy ExecutionStrategy[Services, State]) string {
+	if strategy == nil {
+		return ""
+	}
+	t := reflect.TypeOf(strategy)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name, _, _ := strings.Cut(t.Name(), "[")
+	return name
+}
@@ -0,0 +1,171 @@
+package tango_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phr3nzy/tango"
+)
+
+type exportSpecTestCase struct {
+	name     string
+	strategy tango.ExecutionStrategy[Services, State]
+	expected tango.MachineSpec
+}
+
+func TestMachine_ExportSpec(t *testing.T) {
+	tests := []exportSpecTestCase{
+		{
+			name:     "Sequential",
+			strategy: &tango.SequentialStrategy[Services, State]{},
+			expected: tango.MachineSpec{
+				Name:     "TestMachine",
+				Strategy: "SequentialStrategy",
+				Steps: []tango.StepSpec{
+					{Name: "Reserve", HasCompensate: true, Metadata: map[string]any{"owner": "inventory"}},
+					{Name: "Charge", Retry: &tango.RetrySpec{MaxAttempts: 3, Backoff: time.Second}, FeatureFlag: "billing"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execute := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name:     "Reserve",
+					Execute:  execute,
+					Metadata: map[string]any{"owner": "inventory"},
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				},
+				{
+					Name:        "Charge",
+					Execute:     execute,
+					Retry:       &tango.RetryPolicy{MaxAttempts: 3, Backoff: time.Second},
+					FeatureFlag: "billing",
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, tt.strategy)
+
+			data, err := m.ExportSpec()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var spec tango.MachineSpec
+			if err := json.Unmarshal(data, &spec); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(spec, tt.expected) {
+				t.Errorf("expected spec %+v, got %+v", tt.expected, spec)
+			}
+		})
+	}
+}
+
+type describeTestCase struct {
+	name     string
+	plugins  []tango.Plugin[Services, State]
+	expected tango.MachineInfo
+}
+
+func TestMachine_Describe(t *testing.T) {
+	tests := []describeTestCase{
+		{
+			name: "StepsAndPlugins",
+			plugins: []tango.Plugin[Services, State]{
+				{Name: "audit", Priority: 2},
+				{Name: "metrics", Priority: 1},
+			},
+			expected: tango.MachineInfo{
+				Name:     "TestMachine",
+				Strategy: "ConcurrentStrategy",
+				Steps: []tango.StepSpec{
+					{Name: "Reserve", HasCompensate: true, CompensateTimeout: time.Second},
+					{Name: "Notify", NonCritical: true},
+				},
+				Plugins: []string{"metrics", "audit"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execute := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Next("Next"), nil
+			}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name:              "Reserve",
+					Execute:           execute,
+					CompensateTimeout: time.Second,
+					Compensate: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Compensated"), nil
+					},
+				},
+				{Name: "Notify", Execute: execute, NonCritical: true},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Plugins: tt.plugins,
+			}, &tango.ConcurrentStrategy[Services, State]{Concurrency: 2})
+
+			if info := m.Describe(); !reflect.DeepEqual(info, tt.expected) {
+				t.Errorf("expected info %+v, got %+v", tt.expected, info)
+			}
+			if tt.plugins[0].Name != "audit" {
+				t.Errorf("expected Describe to leave the configured plugins untouched")
+			}
+		})
+	}
+}
+
+type validateWiringTestCase struct {
+	name     string
+	steps    []tango.Step[Services, State]
+	expected []string
+}
+
+func TestMachine_ValidateWiring(t *testing.T) {
+	tests := []validateWiringTestCase{
+		{
+			name: "Compatible",
+			steps: []tango.Step[Services, State]{
+				{Name: "Parse", OutputType: "order"},
+				tango.BarrierStep[Services, State]("Sync"),
+				{Name: "Price", InputType: "order", OutputType: "invoice"},
+				{Name: "Log", InputType: "any"},
+				{Name: "Untyped"},
+			},
+		},
+		{
+			name: "Mismatched",
+			steps: []tango.Step[Services, State]{
+				{Name: "Parse", OutputType: "order"},
+				{Name: "Charge", InputType: "invoice", OutputType: "receipt"},
+				{Name: "Ship", InputType: "order"},
+			},
+			expected: []string{
+				"step Charge expects invoice but step Parse outputs order",
+				"step Ship expects order but step Charge outputs receipt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", tt.steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			var messages []string
+			for _, err := range m.ValidateWiring() {
+				messages = append(messages, err.Error())
+			}
+			if !reflect.DeepEqual(messages, tt.expected) {
+				t.Errorf("expected errors %v, got %v", tt.expected, messages)
+			}
+		})
+	}
+}
@@ -1,15 +1,34 @@
 package tango
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
 // ResponseStatus is a type that represents the status of a response.
 type ResponseStatus string
 
 // ResponseStatus is a type that represents the status of a response.
 const (
-	NEXT  ResponseStatus = "NEXT"
-	DONE  ResponseStatus = "DONE"
-	ERROR ResponseStatus = "ERROR"
-	SKIP  ResponseStatus = "SKIP"
-	JUMP  ResponseStatus = "JUMP"
+	NEXT    ResponseStatus = "NEXT"
+	DONE    ResponseStatus = "DONE"
+	ERROR   ResponseStatus = "ERROR"
+	SKIP    ResponseStatus = "SKIP"
+	JUMP    ResponseStatus = "JUMP"
+	SUSPEND ResponseStatus = "SUSPEND"
+	REQUEUE ResponseStatus = "REQUEUE"
+	// SAVEPOINT continues like NEXT and marks a compensation boundary: a later rollback only
+	// compensates the steps executed after the most recent savepoint, and not the savepoint
+	// step itself.
+	SAVEPOINT ResponseStatus = "SAVEPOINT"
+	// RESTART runs the machine again from its first step under SequentialStrategy. The steps
+	// executed so far are cleared from the history and are not compensated by a later failure.
+	// A custom history that is not a ClearableHistory keeps them; its Reverse must pass the
+	// RESTART step's response for compensation to stop there.
+	RESTART ResponseStatus = "RESTART"
 )
 
 // Response is a struct that represents the response of a step execution.
@@ -19,6 +38,22 @@ type Response[State, Services any] struct {
 	SkipCount  int
 	JumpTarget string
 	NewMachine *Machine[State, Services] // New field to allow nested machine execution
+	// SuspensionID identifies the saved suspension of a run that ended with SUSPEND.
+	SuspensionID string
+	// RequeueAfter is how long to wait before a step that returned REQUEUE runs again.
+	RequeueAfter time.Duration
+	// Warning, when set, is a non-fatal diagnostic the machine records in its warnings.
+	Warning string
+	// Fallback names the fallback step that produced the response in place of the failed step,
+	// and is empty when the step itself produced it.
+	Fallback string
+	// FollowUp, when returned by a compensate function, queues further cleanup discovered
+	// during the rollback. The queued steps' Compensate functions run once the reverse walk is
+	// done, in the order they were queued, and may queue more follow-ups in turn.
+	FollowUp []Step[State, Services]
+	// attempts holds the failed steps tried before the fallback that produced the response,
+	// which are compensated along with it.
+	attempts []attempt[State, Services]
 }
 
 // NewResponse creates a new response.
@@ -31,6 +66,13 @@ func Next[Result, State, Services any](result Result) *Response[State, Services]
 	return NewResponse[Result, State, Services](result, NEXT, 0, "", nil)
 }
 
+// NextWithWarning creates a response with status NEXT that carries a warning.
+func NextWithWarning[Result, State, Services any](result Result, warning string) *Response[State, Services] {
+	response := NewResponse[Result, State, Services](result, NEXT, 0, "", nil)
+	response.Warning = warning
+	return response
+}
+
 // Done creates a response with status DONE.
 func Done[Result, State, Services any](result Result) *Response[State, Services] {
 	return NewResponse[Result, State, Services](result, DONE, 0, "", nil)
@@ -51,7 +93,32 @@ func Jump[Result, State, Services any](result Result, target string) *Response[S
 	return NewResponse[Result, State, Services](result, JUMP, 0, target, nil)
 }
 
-// RunNewMachine creates a response with status NEXT and a new machine.
+// Suspend creates a response with status SUSPEND.
+func Suspend[Result, State, Services any](result Result) *Response[State, Services] {
+	return NewResponse[Result, State, Services](result, SUSPEND, 0, "", nil)
+}
+
+// Requeue creates a response with status REQUEUE, which runs the same step again after the
+// given delay instead of advancing.
+func Requeue[Result, State, Services any](result Result, after time.Duration) *Response[State, Services] {
+	response := NewResponse[Result, State, Services](result, REQUEUE, 0, "", nil)
+	response.RequeueAfter = after
+	return response
+}
+
+// Savepoint creates a response with status SAVEPOINT.
+func Savepoint[Result, State, Services any](result Result) *Response[State, Services] {
+	return NewResponse[Result, State, Services](result, SAVEPOINT, 0, "", nil)
+}
+
+// Restart creates a response with status RESTART.
+func Restart[Result, State, Services any](result Result) *Response[State, Services] {
+	return NewResponse[Result, State, Services](result, RESTART, 0, "", nil)
+}
+
+// RunNewMachine creates a response with status NEXT and a new machine. The new machine runs
+// right after the step; if it fails, the step fails. It runs on the parent's run context, so
+// cancelling the parent also cancels the new machine.
 func RunNewMachine[Result, State, Services any](result Result, newMachine *Machine[State, Services]) *Response[State, Services] {
 	return NewResponse(result, NEXT, 0, "", newMachine)
 }
@@ -65,17 +132,174 @@ type Step[State, Services any] struct {
 	Compensate       func(ctx *MachineContext[State, Services]) (*Response[State, Services], error)
 	BeforeCompensate func(ctx *MachineContext[State, Services]) error
 	AfterCompensate  func(ctx *MachineContext[State, Services]) error
+	// ExpectInput, when set, is the type the previous step's result must be assignable to.
+	ExpectInput reflect.Type
+	// CompensateIf, when set, decides from the failure whether the step is compensated.
+	CompensateIf func(failure FailureInfo) bool
+	// Key, when set, identifies the work a step does. ConcurrentStrategy runs only the first
+	// step for each key.
+	Key string
+	// Fallback, when set, runs in place of the step when it fails, with the same previous
+	// result. The run only compensates if the fallback fails as well, and then compensates the
+	// failed step after the fallback. Only the fallback's response is recorded as the step's
+	// result.
+	Fallback *Step[State, Services]
+	// Fallbacks, when set, are further alternatives tried in order, after Fallback, until one
+	// succeeds. The run only compensates if the last of them fails as well.
+	Fallbacks []*Step[State, Services]
+	// Retry, when set, retries the step's Execute function when it fails.
+	Retry *RetryPolicy
+	// FeatureFlag, when set, names the flag that gates the step. The step runs only when the
+	// machine's FlagProvider reports the flag enabled, and is skipped otherwise.
+	FeatureFlag string
+	// Metadata holds annotations for external tools, such as an owner or an SLA. It does not
+	// affect execution.
+	Metadata map[string]any
+	// CompensateTimeout, when set, bounds the step's Compensate function. The context available
+	// through ctx.Context() is cancelled once the timeout elapses, and a compensate function
+	// still running then is abandoned and the compensation records a timeout error. The function
+	// must watch ctx.Context() and return when it is done, since it cannot be stopped otherwise.
+	// The function runs against a copy of the context whose State is written back if it
+	// returns in time; such functions run one at a time, even when compensations otherwise run
+	// concurrently, so that none of them loses the State changes of another.
+	CompensateTimeout time.Duration
+	// NonCritical marks a best-effort step. When it fails, by returning an error or an ERROR
+	// response, the failure is logged and the run continues with the next step instead of
+	// compensating. Steps are critical by default.
+	NonCritical bool
+	// Memoize, when set, returns a cache key for the step's inputs. A later execution of the
+	// step with the same key reuses the cached response instead of calling Execute. Failed
+	// executions are not cached. See MachineConfig.MemoizeAcrossRuns for the cache lifetime.
+	Memoize func(ctx *MachineContext[State, Services]) string
+	// MustRunAfter names the steps ConcurrentStrategy waits for before it starts this step. A
+	// name shared by several steps waits for all of them. If one of them fails, this step does
+	// not run. Steps are never moved across a barrier, so a step can name a barrier or a step
+	// before it, but not one after the next barrier.
+	MustRunAfter []string
+	// Barrier marks a synchronization point rather than work; see BarrierStep.
+	Barrier bool
+	// MapInput, when set, transforms the previous result before the step runs. The mapped
+	// value is available to the step as ctx.Input.
+	MapInput func(previous *Response[State, Services]) any
+	// Finally, when set, runs once the step is done, whether it succeeded, failed or panicked,
+	// to release resources the step holds. A panic still propagates after Finally returns.
+	Finally func(ctx *MachineContext[State, Services])
+	// GracePeriod, when set, is how long the step may keep running once the machine is shut
+	// down. When it elapses, the context available through ctx.Context() is cancelled so the
+	// step stops, and an error the step then returns wraps ErrShutdown. Steps without a grace
+	// period run until the context passed to Shutdown ends.
+	GracePeriod time.Duration
+	// InputType and OutputType name the type of the value the step consumes and produces, for
+	// tooling such as a workflow editor. They do not affect execution; see
+	// Machine.ValidateWiring.
+	InputType  string
+	OutputType string
+	// Repeat, when above 1, runs the step's Execute function that many times in a row before
+	// the machine moves on, with ctx.Iteration set to each iteration's index. When every
+	// iteration returns NEXT, the step's response is NEXT with the iterations' results in a
+	// []any; an error or any other response ends the repetition and becomes the step's response.
+	Repeat int
+	// MetricLabel, when set, identifies the step to the machine's MetricsRecorder in place of its
+	// name, so that many generated steps can be measured together under one label.
+	MetricLabel string
+	// DoneIf, when set, is evaluated after each execution of the step in a sequential run, once
+	// its response is recorded. When it returns true the run finishes at once with a DONE
+	// response carrying the step's result, whatever status the step returned.
+	DoneIf func(ctx *MachineContext[State, Services]) bool
 }
 
 // NewStep creates a new step.
 func NewStep[State, Services any](step *Step[State, Services]) *Step[State, Services] {
 	return &Step[State, Services]{
-		Name:             step.Name,
-		Execute:          step.Execute,
-		BeforeExecute:    step.BeforeExecute,
-		AfterExecute:     step.AfterExecute,
-		Compensate:       step.Compensate,
-		BeforeCompensate: step.BeforeCompensate,
-		AfterCompensate:  step.AfterCompensate,
+		Name:              step.Name,
+		Execute:           step.Execute,
+		BeforeExecute:     step.BeforeExecute,
+		AfterExecute:      step.AfterExecute,
+		Compensate:        step.Compensate,
+		BeforeCompensate:  step.BeforeCompensate,
+		AfterCompensate:   step.AfterCompensate,
+		ExpectInput:       step.ExpectInput,
+		CompensateIf:      step.CompensateIf,
+		Key:               step.Key,
+		Fallback:          step.Fallback,
+		Fallbacks:         step.Fallbacks,
+		Retry:             step.Retry,
+		FeatureFlag:       step.FeatureFlag,
+		Metadata:          step.Metadata,
+		CompensateTimeout: step.CompensateTimeout,
+		NonCritical:       step.NonCritical,
+		Memoize:           step.Memoize,
+		MustRunAfter:      step.MustRunAfter,
+		Barrier:           step.Barrier,
+		MapInput:          step.MapInput,
+		Finally:           step.Finally,
+		GracePeriod:       step.GracePeriod,
+		InputType:         step.InputType,
+		OutputType:        step.OutputType,
+		Repeat:            step.Repeat,
+		MetricLabel:       step.MetricLabel,
+		DoneIf:            step.DoneIf,
+	}
+}
+
+// metricLabel returns the name the step is measured under.
+func metricLabel[State, Services any](step Step[State, Services]) string {
+	if step.MetricLabel != "" {
+		return step.MetricLabel
+	}
+	return step.Name
+}
+
+// BarrierStep creates a barrier. ConcurrentStrategy starts none of the steps after a barrier
+// until every step before it has finished, and stops at the barrier if one of them failed.
+// Other strategies pass over barriers.
+func BarrierStep[State, Services any](name string) Step[State, Services] {
+	return Step[State, Services]{Name: name, Barrier: true}
+}
+
+// NoOpStep creates a placeholder step that does nothing: its Execute function returns NEXT with
+// a nil result and its Compensate function succeeds without doing anything.
+func NoOpStep[State, Services any](name string) Step[State, Services] {
+	return Step[State, Services]{
+		Name: name,
+		Execute: func(ctx *MachineContext[State, Services]) (*Response[State, Services], error) {
+			return Next[any, State, Services](nil), nil
+		},
+		Compensate: func(ctx *MachineContext[State, Services]) (*Response[State, Services], error) {
+			return nil, nil
+		},
+	}
+}
+
+// WithStepTimeout wraps an Execute function so that it runs with a context, available through
+// ctx.Context(), that expires after d. If fn has not returned by then, the wrapped function
+// returns a timeout error once it does, regardless of its response; fn should watch
+// ctx.Context() to stop early. fn runs against a copy of ctx carrying the deadline, so the
+// context the caller passed is left as it is; changes fn makes to the copy's State are kept.
+func WithStepTimeout[State, Services any](
+	d time.Duration,
+	fn func(ctx *MachineContext[State, Services]) (*Response[State, Services], error),
+) func(ctx *MachineContext[State, Services]) (*Response[State, Services], error) {
+	return func(ctx *MachineContext[State, Services]) (*Response[State, Services], error) {
+		stepCtx, cancel := context.WithTimeout(ctx.Context(), d)
+		defer cancel()
+
+		scoped, merge := ctx.withContext(stepCtx)
+		response, err := fn(scoped)
+		merge()
+		if errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("step timed out after %v: %w", d, stepCtx.Err())
+		}
+		return response, err
+	}
+}
+
+// RunStep runs a single step in isolation: its BeforeExecute, Execute and AfterExecute
+// functions are invoked against ctx and the response is returned. If ctx is not attached to
+// a machine, a machine holding only the step is created so response helpers keep working.
+func RunStep[State, Services any](step Step[State, Services], ctx *MachineContext[State, Services]) (*Response[State, Services], error) {
+	if ctx.Machine == nil {
+		NewMachine(step.Name, []Step[State, Services]{step}, ctx, &MachineConfig[State, Services]{}, &NoOpStrategy[State, Services]{})
 	}
+	return ctx.Machine.executeStep(ctx, step)
 }
@@ -0,0 +1,680 @@
+package tango_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phr3nzy/tango"
+)
+
+type stepTimeoutTestCase struct {
+	name           string
+	timeout        time.Duration
+	work           time.Duration
+	expectedResult string
+	expectTimeout  bool
+}
+
+func TestWithStepTimeout(t *testing.T) {
+	tests := []stepTimeoutTestCase{
+		{
+			name:          "StepOverruns",
+			timeout:       10 * time.Millisecond,
+			work:          time.Second,
+			expectTimeout: true,
+		},
+		{
+			name:           "StepFinishesInTime",
+			timeout:        time.Second,
+			work:           time.Millisecond,
+			expectedResult: "Done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: tango.WithStepTimeout(tt.timeout, func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						select {
+						case <-ctx.Context().Done():
+							return nil, ctx.Context().Err()
+						case <-time.After(tt.work):
+							return ctx.Machine.Done("Done"), nil
+						}
+					}),
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Log: false,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+
+			if tt.expectTimeout {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("expected a timeout error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if response == nil || response.Result != tt.expectedResult {
+				t.Errorf("expected result to be %v, got %v", tt.expectedResult, response)
+			}
+		})
+	}
+}
+
+type stepTimeoutContextTestCase struct {
+	name            string
+	expectedCounter int
+}
+
+func TestWithStepTimeout_ContextCopy(t *testing.T) {
+	tests := []stepTimeoutContextTestCase{
+		{
+			name:            "CallerContextUnchanged",
+			expectedCounter: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &tango.MachineContext[Services, State]{}
+			var outerDeadline, innerDeadline bool
+			execute := tango.WithStepTimeout(time.Second, func(inner *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				_, outerDeadline = ctx.Context().Deadline()
+				_, innerDeadline = inner.Context().Deadline()
+				inner.State.Counter++
+				return nil, nil
+			})
+
+			if _, err := execute(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !innerDeadline {
+				t.Error("expected the wrapped function's context to have a deadline")
+			}
+			if outerDeadline {
+				t.Error("expected the caller's context to be left without a deadline")
+			}
+			if ctx.State.Counter != tt.expectedCounter {
+				t.Errorf("expected counter %d, got %d", tt.expectedCounter, ctx.State.Counter)
+			}
+		})
+	}
+}
+
+type runStepTestCase struct {
+	name           string
+	initialCounter int
+	expectedResult int
+	expectedCalls  []string
+}
+
+func TestRunStep(t *testing.T) {
+	tests := []runStepTestCase{
+		{
+			name:           "IsolatedStep",
+			initialCounter: 41,
+			expectedResult: 42,
+			expectedCalls:  []string{"before", "execute", "after"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			step := tango.Step[Services, State]{
+				Name: "Increment",
+				BeforeExecute: func(ctx *tango.MachineContext[Services, State]) error {
+					calls = append(calls, "before")
+					return nil
+				},
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					calls = append(calls, "execute")
+					ctx.State.Counter++
+					return ctx.Machine.Next(ctx.State.Counter), nil
+				},
+				AfterExecute: func(ctx *tango.MachineContext[Services, State]) error {
+					calls = append(calls, "after")
+					return nil
+				},
+			}
+
+			ctx := &tango.MachineContext[Services, State]{State: State{Counter: tt.initialCounter}}
+			response, err := tango.RunStep(step, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Status != tango.NEXT || response.Result != tt.expectedResult {
+				t.Errorf("expected NEXT with result %v, got %v with %v", tt.expectedResult, response.Status, response.Result)
+			}
+			if ctx.State.Counter != tt.expectedResult {
+				t.Errorf("expected state counter to be %v, got %v", tt.expectedResult, ctx.State.Counter)
+			}
+			if !reflect.DeepEqual(calls, tt.expectedCalls) {
+				t.Errorf("expected calls %v, got %v", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+type memoizeTestCase struct {
+	name               string
+	acrossRuns         bool
+	runs               int
+	expectedExecutions int
+}
+
+func TestMachine_Step_Memoize(t *testing.T) {
+	tests := []memoizeTestCase{
+		{
+			name:               "PerRun",
+			runs:               2,
+			expectedExecutions: 2,
+		},
+		{
+			name:               "AcrossRuns",
+			acrossRuns:         true,
+			runs:               2,
+			expectedExecutions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executions := 0
+			loops := 0
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Lookup",
+					Memoize: func(ctx *tango.MachineContext[Services, State]) string {
+						return ctx.Services.Database
+					},
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						executions++
+						return ctx.Machine.Next("rate-" + ctx.Services.Database), nil
+					},
+				},
+				{
+					Name: "Loop",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						loops++
+						if loops%2 == 1 {
+							return ctx.Machine.Jump(ctx.PreviousResult.Result, "Lookup"), nil
+						}
+						return ctx.Machine.Done(ctx.PreviousResult.Result), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{
+				Services: Services{Database: "MySQL"},
+			}, &tango.MachineConfig[Services, State]{
+				MemoizeAcrossRuns: tt.acrossRuns,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			for i := 0; i < tt.runs; i++ {
+				response, err := m.Run()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if response.Result != "rate-MySQL" {
+					t.Errorf("expected result 'rate-MySQL', got %v", response.Result)
+				}
+				if counts := m.StepExecutionCounts(); counts["Lookup"] != 2 {
+					t.Errorf("expected Lookup to run twice per run, got %d", counts["Lookup"])
+				}
+			}
+			if executions != tt.expectedExecutions {
+				t.Errorf("expected Execute to run %d times, got %d", tt.expectedExecutions, executions)
+			}
+		})
+	}
+}
+
+type sleepTestCase struct {
+	name          string
+	sleep         time.Duration
+	cancelAfter   time.Duration
+	expectedError error
+}
+
+func TestMachineContext_Sleep(t *testing.T) {
+	tests := []sleepTestCase{
+		{
+			name:        "Completes",
+			sleep:       time.Millisecond,
+			cancelAfter: time.Second,
+		},
+		{
+			name:          "Cancelled",
+			sleep:         time.Second,
+			cancelAfter:   10 * time.Millisecond,
+			expectedError: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sleepErr error
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Poll",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						sleepErr = ctx.Sleep(tt.sleep)
+						return ctx.Machine.Done("Done"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			runCtx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			timer := time.AfterFunc(tt.cancelAfter, cancel)
+			defer timer.Stop()
+
+			start := time.Now()
+			if _, err := m.RunContext(runCtx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(sleepErr, tt.expectedError) {
+				t.Errorf("expected sleep error %v, got %v", tt.expectedError, sleepErr)
+			}
+			if elapsed := time.Since(start); elapsed >= time.Second {
+				t.Errorf("expected the sleep to end early, took %v", elapsed)
+			}
+		})
+	}
+}
+
+type mapInputTestCase struct {
+	name           string
+	expectedResult string
+}
+
+func TestMachine_Step_MapInput(t *testing.T) {
+	tests := []mapInputTestCase{
+		{
+			name:           "Pipeline",
+			expectedResult: "ORDER-42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Fetch",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(map[string]int{"order": 42}), nil
+					},
+				},
+				{
+					Name: "Format",
+					MapInput: func(previous *tango.Response[Services, State]) any {
+						return previous.Result.(map[string]int)["order"]
+					},
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(fmt.Sprintf("order-%d", ctx.Input.(int))), nil
+					},
+				},
+				{
+					Name: "Normalize",
+					MapInput: func(previous *tango.Response[Services, State]) any {
+						return strings.ToUpper(previous.Result.(string))
+					},
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(ctx.Input), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Result != tt.expectedResult {
+				t.Errorf("expected result %v, got %v", tt.expectedResult, response.Result)
+			}
+			if m.Context.Input != nil {
+				t.Errorf("expected Input to be cleared after the step, got %v", m.Context.Input)
+			}
+		})
+	}
+}
+
+type mapInputConcurrentTestCase struct {
+	name  string
+	steps int
+}
+
+func TestMachine_Step_MapInput_Concurrent(t *testing.T) {
+	tests := []mapInputConcurrentTestCase{
+		{
+			name:  "EachStepSeesItsOwnInput",
+			steps: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			seen := make(map[string]any)
+			steps := make([]tango.Step[Services, State], tt.steps)
+			for i := range steps {
+				name := fmt.Sprintf("Step%d", i)
+				steps[i] = tango.Step[Services, State]{
+					Name: name,
+					MapInput: func(previous *tango.Response[Services, State]) any {
+						return name
+					},
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						time.Sleep(time.Millisecond)
+						mu.Lock()
+						seen[name] = ctx.Input
+						mu.Unlock()
+						return ctx.Machine.Next(nil), nil
+					},
+				}
+			}
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.ConcurrentStrategy[Services, State]{Concurrency: tt.steps})
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, step := range steps {
+				if seen[step.Name] != step.Name {
+					t.Errorf("expected step %s to see its own input, got %v", step.Name, seen[step.Name])
+				}
+			}
+		})
+	}
+}
+
+type finallyTestCase struct {
+	name        string
+	panic       bool
+	fail        bool
+	expectPanic bool
+}
+
+func TestMachine_Step_Finally(t *testing.T) {
+	tests := []finallyTestCase{
+		{
+			name: "Success",
+		},
+		{
+			name: "Error",
+			fail: true,
+		},
+		{
+			name:        "Panic",
+			panic:       true,
+			expectPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Write",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						order = append(order, "open")
+						if tt.panic {
+							panic("disk full")
+						}
+						if tt.fail {
+							return nil, errors.New("disk full")
+						}
+						return ctx.Machine.Done("Written"), nil
+					},
+					Finally: func(ctx *tango.MachineContext[Services, State]) {
+						order = append(order, "close")
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.expectPanic {
+						t.Errorf("unexpected panic state: %v", r)
+					}
+				}()
+				if _, err := m.Run(); (err != nil) != tt.fail {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+
+			if expected := []string{"open", "close"}; !reflect.DeepEqual(order, expected) {
+				t.Errorf("expected order %v, got %v", expected, order)
+			}
+		})
+	}
+}
+
+type noOpStepTestCase struct {
+	name              string
+	fail              bool
+	expectedExecuted  []string
+	expectCompensated bool
+}
+
+func TestNoOpStep(t *testing.T) {
+	tests := []noOpStepTestCase{
+		{
+			name:             "Run",
+			expectedExecuted: []string{"First", "Second", "Third"},
+		},
+		{
+			name:              "Compensate",
+			fail:              true,
+			expectedExecuted:  []string{"First", "Second", "Third", "Fail"},
+			expectCompensated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := []tango.Step[Services, State]{
+				tango.NoOpStep[Services, State]("First"),
+				tango.NoOpStep[Services, State]("Second"),
+				tango.NoOpStep[Services, State]("Third"),
+			}
+			if tt.fail {
+				fail := tango.NoOpStep[Services, State]("Fail")
+				fail.Execute = func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Error("failed"), nil
+				}
+				steps = append(steps, fail)
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+			outcome := m.RunWithOutcome(context.Background())
+			if !tt.fail {
+				if outcome.Err != nil {
+					t.Fatalf("unexpected error: %v", outcome.Err)
+				}
+				if previous := outcome.PreviousResult; previous.Status != tango.NEXT || previous.Result != nil {
+					t.Errorf("expected a NEXT response with a nil result, got %+v", previous)
+				}
+			}
+
+			var executed []string
+			for _, step := range m.ExecutedSteps {
+				executed = append(executed, step.Name)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedExecuted) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedExecuted, executed)
+			}
+			if outcome.Compensated != tt.expectCompensated {
+				t.Errorf("expected compensated %v, got %v", tt.expectCompensated, outcome.Compensated)
+			}
+			if tt.fail && (outcome.Err == nil || strings.Contains(outcome.Err.Error(), "compensate")) {
+				t.Errorf("expected the run to fail without a compensation error, got %v", outcome.Err)
+			}
+		})
+	}
+}
+
+type repeatTestCase struct {
+	name               string
+	repeat             int
+	stopAt             int
+	expectedIterations []int
+	expectedCounter    int
+	expectedResult     any
+	expectErr          bool
+}
+
+func TestMachine_Step_Repeat(t *testing.T) {
+	tests := []repeatTestCase{
+		{
+			name:               "ThreeTimes",
+			repeat:             3,
+			stopAt:             -1,
+			expectedIterations: []int{0, 1, 2},
+			expectedCounter:    3,
+			expectedResult:     []any{"batch-0", "batch-1", "batch-2"},
+		},
+		{
+			name:               "NotRepeated",
+			stopAt:             -1,
+			expectedIterations: []int{0},
+			expectedCounter:    1,
+			expectedResult:     "batch-0",
+		},
+		{
+			name:               "StopsAtError",
+			repeat:             3,
+			stopAt:             1,
+			expectedIterations: []int{0, 1},
+			expectedCounter:    2,
+			expectErr:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var iterations []int
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name:   "Import",
+					Repeat: tt.repeat,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						iterations = append(iterations, ctx.Iteration)
+						ctx.State.Counter++
+						if ctx.Iteration == tt.stopAt {
+							return nil, errors.New("batch rejected")
+						}
+						return ctx.Machine.Next(fmt.Sprintf("batch-%d", ctx.Iteration)), nil
+					},
+				},
+				{
+					Name: "Report",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(ctx.PreviousResult.Result), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			response, err := m.Run()
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(iterations, tt.expectedIterations) {
+				t.Errorf("expected iterations %v, got %v", tt.expectedIterations, iterations)
+			}
+			if !tt.expectErr && !reflect.DeepEqual(response.Result, tt.expectedResult) {
+				t.Errorf("expected result %v, got %v", tt.expectedResult, response.Result)
+			}
+			if m.Context.Iteration != 0 {
+				t.Errorf("expected Iteration to be left unset on the machine context, got %d", m.Context.Iteration)
+			}
+			if m.Context.State.Counter != tt.expectedCounter {
+				t.Errorf("expected counter %d, got %d", tt.expectedCounter, m.Context.State.Counter)
+			}
+		})
+	}
+}
+
+type timeoutExecutor struct {
+	timeout time.Duration
+	invoked []string
+}
+
+func (e *timeoutExecutor) Execute(ctx *tango.MachineContext[Services, State], step tango.Step[Services, State]) (*tango.Response[Services, State], error) {
+	e.invoked = append(e.invoked, step.Name)
+	return tango.WithStepTimeout(e.timeout, step.Execute)(ctx)
+}
+
+type stepExecutorTestCase struct {
+	name            string
+	work            time.Duration
+	expectedInvoked []string
+	expectTimeout   bool
+}
+
+func TestMachineConfig_Executor(t *testing.T) {
+	tests := []stepExecutorTestCase{
+		{
+			name:            "WithinTimeout",
+			expectedInvoked: []string{"Fetch", "Store"},
+		},
+		{
+			name:            "TimedOut",
+			work:            time.Second,
+			expectedInvoked: []string{"Fetch"},
+			expectTimeout:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &timeoutExecutor{timeout: 20 * time.Millisecond}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Fetch",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						if err := ctx.Sleep(tt.work); err != nil {
+							return nil, err
+						}
+						return ctx.Machine.Next("fetched"), nil
+					},
+				},
+				{
+					Name: "Store",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("stored"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Executor: executor,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			start := time.Now()
+			_, err := m.Run()
+			if tt.expectTimeout != errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed >= tt.work && tt.work > 0 {
+				t.Errorf("expected the executor to stop the step early, took %v", elapsed)
+			}
+			if !reflect.DeepEqual(executor.invoked, tt.expectedInvoked) {
+				t.Errorf("expected invocations %v, got %v", tt.expectedInvoked, executor.invoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package tango
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Suspension is the persisted position and state of a suspended run. Steps are code and are
+// not persisted, so a suspension is resumed onto a machine built with the same steps.
+type Suspension[State any] struct {
+	Machine string
+	Step    string
+	State   State
+	Result  interface{}
+}
+
+// SuspendStore persists suspended runs.
+type SuspendStore[State any] interface {
+	// Save persists the suspension and returns its ID.
+	Save(suspension Suspension[State]) (string, error)
+	// Load returns the suspension with the given ID.
+	Load(id string) (Suspension[State], error)
+	// Delete removes the suspension with the given ID.
+	Delete(id string) error
+}
+
+// suspend saves the run suspended by step and returns its response carrying the suspension ID.
+func (m *Machine[Services, State]) suspend(step Step[Services, State], response *Response[Services, State]) (*Response[Services, State], error) {
+	if m.Config.SuspendStore == nil {
+		return nil, fmt.Errorf("step %s suspended the run but no suspend store is configured", step.Name)
+	}
+	id, err := m.Config.SuspendStore.Save(Suspension[State]{
+		Machine: m.Name,
+		Step:    step.Name,
+		State:   m.Context.State,
+		Result:  response.Result,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("suspend error: %v", err)
+	}
+	response.SuspensionID = id
+	return response, nil
+}
+
+// Resume continues a suspended run from the step after the one that suspended it, restoring
+// the saved state and making the suspending step's result the previous result. The suspension
+// is removed from the store once it has been loaded. Like RunFrom, Resume looks the step up
+// among the machine's own steps, so a run suspended by a step a plugin contributed cannot be
+// resumed; steps plugins prepend are skipped and steps they append run again. A run suspended
+// by the machine's last step resumes with no steps of its own left to run, and returns DONE with
+// the suspending step's result unless a step a plugin appended returned a response.
+func (m *Machine[Services, State]) Resume(store SuspendStore[State], id string) (*Response[Services, State], error) {
+	suspension, err := store.Load(id)
+	if err != nil {
+		return nil, fmt.Errorf("resume error: %v", err)
+	}
+	if suspension.Machine != m.Name {
+		return nil, fmt.Errorf("suspension %s belongs to machine %s, not %s", id, suspension.Machine, m.Name)
+	}
+	index := m.stepIndex(suspension.Step)
+	if index < 0 {
+		return nil, fmt.Errorf("suspended step '%s' not found", suspension.Step)
+	}
+	if err := store.Delete(id); err != nil {
+		return nil, fmt.Errorf("resume error: %v", err)
+	}
+
+	m.Context.State = suspension.State
+	m.Context.PreviousResult = &Response[Services, State]{Result: suspension.Result, Status: SUSPEND}
+
+	m.start, m.fromStep = index+1, true
+	defer func() { m.start, m.fromStep = 0, false }()
+	response, err := m.Run()
+	if err == nil && response == nil && index+1 == len(m.Steps) {
+		return m.Done(suspension.Result), nil
+	}
+	return response, err
+}
+
+// MemorySuspendStore is an in-memory SuspendStore.
+type MemorySuspendStore[State any] struct {
+	mu          sync.Mutex
+	next        int
+	suspensions map[string]Suspension[State]
+}
+
+// Save stores the suspension under a new ID.
+func (s *MemorySuspendStore[State]) Save(suspension Suspension[State]) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.suspensions == nil {
+		s.suspensions = make(map[string]Suspension[State])
+	}
+	s.next++
+	id := strconv.Itoa(s.next)
+	s.suspensions[id] = suspension
+	return id, nil
+}
+
+// Load returns the suspension with the given ID.
+func (s *MemorySuspendStore[State]) Load(id string) (Suspension[State], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	suspension, ok := s.suspensions[id]
+	if !ok {
+		return suspension, fmt.Errorf("suspension %s not found", id)
+	}
+	return suspension, nil
+}
+
+// Delete removes the suspension with the given ID.
+func (s *MemorySuspendStore[State]) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.suspensions, id)
+	return nil
+}
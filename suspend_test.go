@@ -0,0 +1,103 @@
+package tango_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type suspendTestCase struct {
+	name              string
+	suspendAt         string
+	expectedStatus    tango.ResponseStatus
+	expectedResult    string
+	expectedCounter   int
+	expectedStepNames []string
+}
+
+func TestMachine_SuspendResume(t *testing.T) {
+	tests := []suspendTestCase{
+		{
+			name:              "SuspendAfterFirstStep",
+			suspendAt:         "Step1",
+			expectedStatus:    tango.DONE,
+			expectedResult:    "approved",
+			expectedCounter:   2,
+			expectedStepNames: []string{"Step2"},
+		},
+		{
+			name:            "SuspendOnLastStep",
+			suspendAt:       "Step2",
+			expectedStatus:  tango.DONE,
+			expectedResult:  "awaiting approval",
+			expectedCounter: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &tango.MemorySuspendStore[State]{}
+			var executed []string
+
+			newMachine := func() *tango.Machine[Services, State] {
+				executed = nil
+				return tango.NewMachine("approval", []tango.Step[Services, State]{
+					{
+						Name: "Step1",
+						Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+							executed = append(executed, "Step1")
+							ctx.State.Counter++
+							if tt.suspendAt == "Step1" {
+								return ctx.Machine.Suspend("awaiting approval"), nil
+							}
+							return ctx.Machine.Next("started"), nil
+						},
+					},
+					{
+						Name: "Step2",
+						Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+							executed = append(executed, "Step2")
+							if tt.suspendAt == "Step2" {
+								ctx.State.Counter++
+								return ctx.Machine.Suspend("awaiting approval"), nil
+							}
+							if ctx.PreviousResult.Result != "awaiting approval" {
+								return ctx.Machine.Error("unexpected previous result"), nil
+							}
+							ctx.State.Counter++
+							return ctx.Machine.Done("approved"), nil
+						},
+					},
+				}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+					Log:          false,
+					SuspendStore: store,
+				}, &tango.SequentialStrategy[Services, State]{})
+			}
+
+			response, err := newMachine().Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.Status != tango.SUSPEND || response.SuspensionID == "" {
+				t.Fatalf("expected a suspended response with an ID, got %v", response)
+			}
+
+			resumed := newMachine()
+			response, err = resumed.Resume(store, response.SuspensionID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response == nil || response.Status != tt.expectedStatus || response.Result != tt.expectedResult {
+				t.Errorf("expected %v with result %v, got %v", tt.expectedStatus, tt.expectedResult, response)
+			}
+			if resumed.Context.State.Counter != tt.expectedCounter {
+				t.Errorf("expected state counter to be %v, got %v", tt.expectedCounter, resumed.Context.State.Counter)
+			}
+			if !reflect.DeepEqual(executed, tt.expectedStepNames) {
+				t.Errorf("expected resumed steps %v, got %v", tt.expectedStepNames, executed)
+			}
+		})
+	}
+}
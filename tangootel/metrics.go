@@ -0,0 +1,80 @@
+// Package tangootel records tango machine metrics with OpenTelemetry.
+package tangootel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Metrics is a tango.MetricsRecorder that records step measurements as OpenTelemetry
+// instruments:
+//
+//   - tango.step.duration, a histogram of step execution times in seconds
+//   - tango.step.executions, a counter of step executions
+//   - tango.step.errors, a counter of failed step executions
+//   - tango.step.compensations, a counter of compensated steps
+//
+// Every measurement carries the machine and step names as attributes.
+type Metrics struct {
+	duration      metric.Float64Histogram
+	executions    metric.Int64Counter
+	errors        metric.Int64Counter
+	compensations metric.Int64Counter
+}
+
+// NewMetrics creates the instruments on meter.
+func NewMetrics(meter metric.Meter) (*Metrics, error) {
+	duration, err := meter.Float64Histogram("tango.step.duration",
+		metric.WithDescription("Duration of step executions."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	executions, err := meter.Int64Counter("tango.step.executions",
+		metric.WithDescription("Number of step executions."))
+	if err != nil {
+		return nil, err
+	}
+	failures, err := meter.Int64Counter("tango.step.errors",
+		metric.WithDescription("Number of failed step executions."))
+	if err != nil {
+		return nil, err
+	}
+	compensations, err := meter.Int64Counter("tango.step.compensations",
+		metric.WithDescription("Number of compensated steps."))
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{
+		duration:      duration,
+		executions:    executions,
+		errors:        failures,
+		compensations: compensations,
+	}, nil
+}
+
+// StepExecuted records the duration of a step execution and whether it failed.
+func (m *Metrics) StepExecuted(ctx context.Context, machine, step string, duration time.Duration, failed bool) {
+	attrs := metric.WithAttributes(attributes(machine, step)...)
+	m.duration.Record(ctx, duration.Seconds(), attrs)
+	m.executions.Add(ctx, 1, attrs)
+	if failed {
+		m.errors.Add(ctx, 1, attrs)
+	}
+}
+
+// StepCompensated records a compensated step.
+func (m *Metrics) StepCompensated(ctx context.Context, machine, step string) {
+	m.compensations.Add(ctx, 1, metric.WithAttributes(attributes(machine, step)...))
+}
+
+// attributes returns the attributes identifying a step.
+func attributes(machine, step string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("tango.machine", machine),
+		attribute.String("tango.step", step),
+	}
+}
@@ -0,0 +1,123 @@
+package tangootel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+	"github.com/phr3nzy/tango/tangootel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type Services struct{}
+
+type State struct{}
+
+type metricsTestCase struct {
+	name                  string
+	expectedExecutions    map[string]int64
+	expectedErrors        map[string]int64
+	expectedCompensations map[string]int64
+}
+
+func TestMetrics(t *testing.T) {
+	tests := []metricsTestCase{
+		{
+			name:                  "FailedRun",
+			expectedExecutions:    map[string]int64{"Step1": 1, "Step2": 1},
+			expectedErrors:        map[string]int64{"Step2": 1},
+			expectedCompensations: map[string]int64{"Step1": 1, "Step2": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+			metrics, err := tangootel.NewMetrics(provider.Meter("tango"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			compensate := func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return ctx.Machine.Done("Compensated"), nil
+			}
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name: "Step1",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next("Next"), nil
+					},
+					Compensate: compensate,
+				},
+				{
+					Name: "Step2",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Error("failed"), nil
+					},
+					Compensate: compensate,
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				Metrics: metrics,
+			}, &tango.SequentialStrategy[Services, State]{})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatalf("expected an error")
+			}
+
+			var data metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &data); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertCounts(t, data, "tango.step.executions", tt.expectedExecutions)
+			assertCounts(t, data, "tango.step.errors", tt.expectedErrors)
+			assertCounts(t, data, "tango.step.compensations", tt.expectedCompensations)
+
+			durations := find(data, "tango.step.duration")
+			histogram, ok := durations.Data.(metricdata.Histogram[float64])
+			if !ok || len(histogram.DataPoints) != len(tt.expectedExecutions) {
+				t.Errorf("expected a duration data point per step, got %+v", durations.Data)
+			}
+		})
+	}
+}
+
+// assertCounts checks the per-step values of the named counter.
+func assertCounts(t *testing.T, data metricdata.ResourceMetrics, name string, expected map[string]int64) {
+	t.Helper()
+	sum, ok := find(data, name).Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("expected counter %s to be recorded", name)
+	}
+	counts := make(map[string]int64)
+	for _, point := range sum.DataPoints {
+		if machine, _ := point.Attributes.Value(attribute.Key("tango.machine")); machine.AsString() != "TestMachine" {
+			t.Errorf("expected machine attribute TestMachine on %s, got %q", name, machine.AsString())
+		}
+		step, _ := point.Attributes.Value(attribute.Key("tango.step"))
+		counts[step.AsString()] = point.Value
+	}
+	for step, value := range expected {
+		if counts[step] != value {
+			t.Errorf("expected %s for %s to be %d, got %d", name, step, value, counts[step])
+		}
+	}
+	if len(counts) != len(expected) {
+		t.Errorf("expected %s for steps %v, got %v", name, expected, counts)
+	}
+}
+
+// find returns the named metric, or an empty one.
+func find(data metricdata.ResourceMetrics, name string) metricdata.Metrics {
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	return metricdata.Metrics{}
+}
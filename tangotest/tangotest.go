@@ -0,0 +1,77 @@
+// Package tangotest provides helpers for testing tango machines.
+package tangotest
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+// AssertStateRestored fails the test unless after deeply equals before, which is what a
+// correct compensation leaves behind. The failure lists every field that differs.
+func AssertStateRestored[State any](t testing.TB, before, after State) {
+	t.Helper()
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	var lines []string
+	diff(&lines, "State", reflect.ValueOf(before), reflect.ValueOf(after))
+	t.Errorf("state was not restored by compensation:\n%s", strings.Join(lines, "\n"))
+}
+
+// AssertCompensated fails the test unless the machine's last run compensated the given steps,
+// listed in the order they executed, in reverse order. Other compensated steps are ignored.
+func AssertCompensated[Services, State any](t testing.TB, m *tango.Machine[Services, State], steps ...string) {
+	t.Helper()
+	expected := slices.Clone(steps)
+	slices.Reverse(expected)
+	var compensated []string
+	for _, name := range m.CompensatedSteps() {
+		if slices.Contains(steps, name) {
+			compensated = append(compensated, name)
+		}
+	}
+	if !slices.Equal(compensated, expected) {
+		t.Errorf("expected steps to be compensated in the order %v, got %v (all compensated steps: %v)", expected, compensated, m.CompensatedSteps())
+	}
+}
+
+// diff appends a line for every field of a and b that differs, descending into structs.
+func diff(lines *[]string, path string, a, b reflect.Value) {
+	if a.IsValid() && b.IsValid() && a.Kind() == reflect.Struct && a.Type() == b.Type() {
+		for i := 0; i < a.NumField(); i++ {
+			diff(lines, path+"."+a.Type().Field(i).Name, a.Field(i), b.Field(i))
+		}
+		return
+	}
+	if reflect.DeepEqual(valueOf(a), valueOf(b)) {
+		return
+	}
+	*lines = append(*lines, fmt.Sprintf("  %s: before %s, after %s", path, format(a), format(b)))
+}
+
+// valueOf returns the value held by v, reading unexported fields through their kind.
+func valueOf(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return format(v)
+}
+
+// format renders v for a diff line.
+func format(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<nil>"
+	}
+	if v.CanInterface() {
+		return fmt.Sprintf("%#v", v.Interface())
+	}
+	return fmt.Sprintf("%v", v)
+}
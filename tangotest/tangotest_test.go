@@ -0,0 +1,145 @@
+package tangotest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+	"github.com/phr3nzy/tango/tangotest"
+)
+
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+type cart struct {
+	Items    int
+	Reserved bool
+	Owner    string
+}
+
+type assertStateRestoredTestCase struct {
+	name          string
+	before        cart
+	after         cart
+	expectedLines []string
+}
+
+func TestAssertStateRestored(t *testing.T) {
+	tests := []assertStateRestoredTestCase{
+		{
+			name:   "Restored",
+			before: cart{Items: 2, Owner: "ada"},
+			after:  cart{Items: 2, Owner: "ada"},
+		},
+		{
+			name:          "NotRestored",
+			before:        cart{Items: 2, Owner: "ada"},
+			after:         cart{Items: 3, Reserved: true, Owner: "ada"},
+			expectedLines: []string{"State.Items: before 2, after 3", "State.Reserved: before false, after true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tangotest.AssertStateRestored(r, tt.before, tt.after)
+
+			if tt.expectedLines == nil {
+				if len(r.failures) != 0 {
+					t.Errorf("expected no failure, got %v", r.failures)
+				}
+				return
+			}
+			if len(r.failures) != 1 {
+				t.Fatalf("expected one failure, got %v", r.failures)
+			}
+			for _, line := range tt.expectedLines {
+				if !strings.Contains(r.failures[0], line) {
+					t.Errorf("expected failure to contain %q, got %q", line, r.failures[0])
+				}
+			}
+			if strings.Contains(r.failures[0], "Owner") {
+				t.Errorf("expected unchanged fields to be left out, got %q", r.failures[0])
+			}
+		})
+	}
+}
+
+type assertCompensatedTestCase struct {
+	name          string
+	steps         []string
+	expectFailure bool
+}
+
+func TestAssertCompensated(t *testing.T) {
+	tests := []assertCompensatedTestCase{
+		{
+			name:  "ReverseOrder",
+			steps: []string{"Reserve", "Charge"},
+		},
+		{
+			name:  "SingleStep",
+			steps: []string{"Charge"},
+		},
+		{
+			name:          "WrongOrder",
+			steps:         []string{"Charge", "Reserve"},
+			expectFailure: true,
+		},
+		{
+			name:          "NotCompensated",
+			steps:         []string{"Reserve", "Refund"},
+			expectFailure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compensate := func(ctx *tango.MachineContext[struct{}, cart]) (*tango.Response[struct{}, cart], error) {
+				return nil, nil
+			}
+			m := tango.NewMachine("TestMachine", []tango.Step[struct{}, cart]{
+				{
+					Name: "Reserve",
+					Execute: func(ctx *tango.MachineContext[struct{}, cart]) (*tango.Response[struct{}, cart], error) {
+						return ctx.Machine.Next("reserved"), nil
+					},
+					Compensate: compensate,
+				},
+				{
+					Name: "Charge",
+					Execute: func(ctx *tango.MachineContext[struct{}, cart]) (*tango.Response[struct{}, cart], error) {
+						return ctx.Machine.Next("charged"), nil
+					},
+					Compensate: compensate,
+				},
+				{
+					Name: "Ship",
+					Execute: func(ctx *tango.MachineContext[struct{}, cart]) (*tango.Response[struct{}, cart], error) {
+						return ctx.Machine.Error("carrier unavailable"), nil
+					},
+					Compensate: compensate,
+				},
+			}, &tango.MachineContext[struct{}, cart]{}, &tango.MachineConfig[struct{}, cart]{}, &tango.SequentialStrategy[struct{}, cart]{})
+
+			if _, err := m.Run(); err == nil {
+				t.Fatal("expected the run to fail")
+			}
+
+			r := &recorder{TB: t}
+			tangotest.AssertCompensated(r, m, tt.steps...)
+			if failed := len(r.failures) > 0; failed != tt.expectFailure {
+				t.Errorf("expected failure %v, got %v", tt.expectFailure, r.failures)
+			}
+		})
+	}
+}
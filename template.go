@@ -0,0 +1,44 @@
+package tango
+
+// Template builds a family of machines that differ by parameters, such as URLs or table
+// names. Machines it builds are ordinary machines: they can be cloned and used as the
+// template of RunBatch.
+type Template[P, Services, State any] struct {
+	// Name returns the name of the machine built for params.
+	Name func(params P) string
+	// Steps generates the steps of the machine built for params.
+	Steps func(params P) []Step[Services, State]
+	// Context, when set, returns the initial context of the machine built for params. By
+	// default the machine starts from an empty context.
+	Context func(params P) *MachineContext[Services, State]
+	// Config is shared by the machines the template builds. By default they get an empty one.
+	Config *MachineConfig[Services, State]
+	// Strategy, when set, returns the strategy of each machine. By default machines run
+	// sequentially.
+	Strategy func() ExecutionStrategy[Services, State]
+}
+
+// Build creates the machine for params.
+func (t *Template[P, Services, State]) Build(params P) *Machine[Services, State] {
+	var name string
+	if t.Name != nil {
+		name = t.Name(params)
+	}
+
+	context := &MachineContext[Services, State]{}
+	if t.Context != nil {
+		context = t.Context(params)
+	}
+
+	config := t.Config
+	if config == nil {
+		config = &MachineConfig[Services, State]{}
+	}
+
+	var strategy ExecutionStrategy[Services, State] = &SequentialStrategy[Services, State]{}
+	if t.Strategy != nil {
+		strategy = t.Strategy()
+	}
+
+	return NewMachine(name, t.Steps(params), context, config, strategy)
+}
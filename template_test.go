@@ -0,0 +1,76 @@
+package tango_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type tableParams struct {
+	Table string
+}
+
+type templateTestCase struct {
+	name            string
+	params          tableParams
+	expectedName    string
+	expectedResults []interface{}
+}
+
+func TestTemplate_Build(t *testing.T) {
+	template := &tango.Template[tableParams, Services, State]{
+		Name: func(params tableParams) string {
+			return "Export-" + params.Table
+		},
+		Steps: func(params tableParams) []tango.Step[Services, State] {
+			return []tango.Step[Services, State]{
+				{
+					Name: "Export",
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done(fmt.Sprintf("%s:%s:%d", ctx.Services.Database, params.Table, ctx.State.Counter)), nil
+					},
+				},
+			}
+		},
+		Context: func(params tableParams) *tango.MachineContext[Services, State] {
+			return &tango.MachineContext[Services, State]{Services: Services{Database: "MySQL"}}
+		},
+	}
+
+	tests := []templateTestCase{
+		{
+			name:            "Users",
+			params:          tableParams{Table: "users"},
+			expectedName:    "Export-users",
+			expectedResults: []interface{}{"MySQL:users:1", "MySQL:users:2"},
+		},
+		{
+			name:            "Orders",
+			params:          tableParams{Table: "orders"},
+			expectedName:    "Export-orders",
+			expectedResults: []interface{}{"MySQL:orders:1", "MySQL:orders:2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := template.Build(tt.params)
+			if m.Name != tt.expectedName {
+				t.Errorf("expected name %s, got %s", tt.expectedName, m.Name)
+			}
+
+			var results []interface{}
+			for _, outcome := range tango.RunBatch(m, []State{{Counter: 1}, {Counter: 2}}, 2) {
+				if outcome.Err != nil {
+					t.Fatalf("unexpected error: %v", outcome.Err)
+				}
+				results = append(results, outcome.Response.Result)
+			}
+			if !reflect.DeepEqual(results, tt.expectedResults) {
+				t.Errorf("expected results %v, got %v", tt.expectedResults, results)
+			}
+		})
+	}
+}
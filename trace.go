@@ -0,0 +1,124 @@
+package tango
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Decision records the control-flow outcome of one executed step.
+type Decision struct {
+	Step       string         `json:"step"`
+	Status     ResponseStatus `json:"status"`
+	SkipCount  int            `json:"skip_count,omitempty"`
+	JumpTarget string         `json:"jump_target,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// Trace is the recorded sequence of decisions made during a run.
+type Trace struct {
+	Machine   string     `json:"machine"`
+	Decisions []Decision `json:"decisions"`
+}
+
+// Trace returns the decisions recorded during the last run.
+func (m *Machine[Services, State]) Trace() Trace {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	decisions := make([]Decision, len(m.decisions))
+	copy(decisions, m.decisions)
+	return Trace{Machine: m.Name, Decisions: decisions}
+}
+
+// DecisionLog returns the control-flow decisions made during the last run, in order. Passing
+// it to Replay, or to a ReplayStrategy, forces the same decisions again.
+func (m *Machine[Services, State]) DecisionLog() []Decision {
+	return m.Trace().Decisions
+}
+
+// ExportScript returns the decisions recorded during the last run as a script; see
+// Trace.Script.
+func (m *Machine[Services, State]) ExportScript() string {
+	return m.Trace().Script()
+}
+
+// Script renders the trace as one line per decision, in order: the step and its status,
+// followed by the jump target for JUMP and the skip count for SKIP. For example:
+//
+//	Reserve -> NEXT
+//	Check -> JUMP -> Ship
+//	Ship -> SKIP 1
+//	Notify -> DONE
+//
+// Unlike the JSON form it is terse and diff friendly, for pasting into tests and reviews.
+func (t Trace) Script() string {
+	var b strings.Builder
+	for _, decision := range t.Decisions {
+		fmt.Fprintf(&b, "%s -> %s", decision.Step, decision.Status)
+		switch decision.Status {
+		case JUMP:
+			fmt.Fprintf(&b, " -> %s", decision.JumpTarget)
+		case SKIP:
+			fmt.Fprintf(&b, " %d", decision.SkipCount)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Replay re-executes the step sequence recorded in trace. It replays control-flow decisions,
+// not outputs: every step in the trace runs again and the recorded status, jump and skip
+// decide what happens next, whatever the step returns this time. Steps are usually given
+// mock Services so the replay is free of side effects.
+func (m *Machine[Services, State]) Replay(trace Trace) (*Response[Services, State], error) {
+	strategy := m.Strategy
+	defer func() { m.Strategy = strategy }()
+	return m.run(context.Background(), &ReplayStrategy[Services, State]{Trace: trace})
+}
+
+// ReplayStrategy runs steps in the order recorded by a trace, following its decisions. The
+// recorded decision replaces the status, skip count and jump target of each step's response,
+// so the replay's decision log matches the trace.
+type ReplayStrategy[Services, State any] struct {
+	Trace Trace
+}
+
+func (r *ReplayStrategy[Services, State]) Execute(m *Machine[Services, State]) (*Response[Services, State], error) {
+	for _, decision := range r.Trace.Decisions {
+		index := m.stepIndex(decision.Step)
+		if index < 0 {
+			return nil, fmt.Errorf("replay step '%s' not found", decision.Step)
+		}
+		step := m.Steps[index]
+
+		if err := m.checkStop(step); err != nil {
+			return m.stop(err)
+		}
+
+		response, err := m.executeStep(m.Context, step)
+		if err != nil {
+			m.deadLetter(step, err)
+			return nil, err
+		}
+
+		forced := *response
+		forced.Status, forced.SkipCount, forced.JumpTarget = decision.Status, decision.SkipCount, decision.JumpTarget
+		response = &forced
+		m.recordStep(step, response)
+
+		switch decision.Status {
+		case DONE:
+			return response, nil
+		case ERROR:
+			err := fmt.Errorf("step %s failed: %v", step.Name, response.Result)
+			return m.fail(step, FailureInfo{Step: step.Name, Result: response.Result}, err)
+		}
+	}
+
+	return nil, nil
+}
+
+// Compensate runs the compensate functions of the replayed steps in reverse order.
+func (r *ReplayStrategy[Services, State]) Compensate(m *Machine[Services, State]) (*Response[Services, State], error) {
+	return (&SequentialStrategy[Services, State]{}).Compensate(m)
+}
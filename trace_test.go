@@ -0,0 +1,327 @@
+package tango_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/phr3nzy/tango"
+)
+
+type replayTestCase struct {
+	name              string
+	recordServices    Services
+	replayServices    Services
+	expectedStepNames []string
+	expectedResult    string
+}
+
+func TestMachine_Replay(t *testing.T) {
+	tests := []replayTestCase{
+		{
+			name:              "ReplayJump",
+			recordServices:    Services{Database: "MySQL"},
+			replayServices:    Services{Database: "mock"},
+			expectedStepNames: []string{"Step1", "Step3"},
+			expectedResult:    "Done",
+		},
+	}
+
+	newMachine := func(services Services) *tango.Machine[Services, State] {
+		return tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+			{
+				Name: "Step1",
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					if ctx.Services.Database == "MySQL" {
+						return ctx.Machine.Jump("Jump", "Step3"), nil
+					}
+					return ctx.Machine.Next("Next"), nil
+				},
+			},
+			{
+				Name: "Step2",
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Error("I got skipped"), nil
+				},
+			},
+			{
+				Name: "Step3",
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Done("Done"), nil
+				},
+			},
+		}, &tango.MachineContext[Services, State]{Services: services}, &tango.MachineConfig[Services, State]{
+			Log: false,
+		}, &tango.SequentialStrategy[Services, State]{})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorded := newMachine(tt.recordServices)
+			if _, err := recorded.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			trace := recorded.Trace()
+
+			replayed := newMachine(tt.replayServices)
+			response, err := replayed.Replay(trace)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response == nil {
+				t.Errorf("expected response to be non-nil")
+			} else if response.Result != tt.expectedResult {
+				t.Errorf("expected result to be %v, got %v", tt.expectedResult, response.Result)
+			}
+
+			var stepNames []string
+			for _, step := range replayed.ExecutedSteps {
+				stepNames = append(stepNames, step.Name)
+			}
+			if !reflect.DeepEqual(stepNames, tt.expectedStepNames) {
+				t.Errorf("expected executed steps %v, got %v", tt.expectedStepNames, stepNames)
+			}
+			if _, ok := replayed.Strategy.(*tango.SequentialStrategy[Services, State]); !ok {
+				t.Errorf("expected strategy to be restored after replay, got %T", replayed.Strategy)
+			}
+		})
+	}
+}
+
+type stepMetadataTestCase struct {
+	name             string
+	metadata         map[string]any
+	expectedMetadata map[string]any
+	expectedJSON     string
+}
+
+func TestMachine_StepMetadata(t *testing.T) {
+	tests := []stepMetadataTestCase{
+		{
+			name:             "OwnerAndSLA",
+			metadata:         map[string]any{"owner": "payments", "sla": "5m"},
+			expectedMetadata: map[string]any{"owner": "payments", "sla": "5m"},
+			expectedJSON:     `"metadata":{"owner":"payments","sla":"5m"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", []tango.Step[Services, State]{
+				{
+					Name:     "Charge",
+					Metadata: tt.metadata,
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Done("Charged"), nil
+					},
+				},
+			}, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+
+			if metadata := m.StepMetadata("Charge"); !reflect.DeepEqual(metadata, tt.expectedMetadata) {
+				t.Errorf("expected metadata %v, got %v", tt.expectedMetadata, metadata)
+			}
+			if metadata := m.StepMetadata("Missing"); metadata != nil {
+				t.Errorf("expected no metadata for a missing step, got %v", metadata)
+			}
+
+			clone := m.Clone()
+			tt.metadata["owner"] = "changed"
+			if metadata := clone.StepMetadata("Charge"); !reflect.DeepEqual(metadata, tt.expectedMetadata) {
+				t.Errorf("expected the clone to keep metadata %v, got %v", tt.expectedMetadata, metadata)
+			}
+
+			if _, err := clone.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, err := json.Marshal(clone.Trace())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(data), tt.expectedJSON) {
+				t.Errorf("expected trace %s to contain %s", data, tt.expectedJSON)
+			}
+		})
+	}
+}
+
+type onResultTestCase struct {
+	name            string
+	strategy        tango.ExecutionStrategy[Services, State]
+	expectedResults map[string]interface{}
+}
+
+func TestMachine_OnResult(t *testing.T) {
+	tests := []onResultTestCase{
+		{
+			name:            "Sequential",
+			strategy:        &tango.SequentialStrategy[Services, State]{},
+			expectedResults: map[string]interface{}{"Step1": 1, "Step2": 2, "Step3": 3},
+		},
+		{
+			name:            "Concurrent",
+			strategy:        &tango.ConcurrentStrategy[Services, State]{Concurrency: 3},
+			expectedResults: map[string]interface{}{"Step1": 1, "Step2": 2, "Step3": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var order []string
+			results := make(map[string]interface{})
+
+			var steps []tango.Step[Services, State]
+			for i := 1; i <= 3; i++ {
+				result := i
+				steps = append(steps, tango.Step[Services, State]{
+					Name: fmt.Sprintf("Step%d", i),
+					Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+						return ctx.Machine.Next(result), nil
+					},
+				})
+			}
+
+			m := tango.NewMachine("TestMachine", steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{
+				OnResult: func(ctx *tango.MachineContext[Services, State], step string, response *tango.Response[Services, State]) {
+					mu.Lock()
+					defer mu.Unlock()
+					order = append(order, step)
+					results[step] = response.Result
+				},
+			}, tt.strategy)
+
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(results, tt.expectedResults) {
+				t.Errorf("expected results %v, got %v", tt.expectedResults, results)
+			}
+			if _, ok := tt.strategy.(*tango.SequentialStrategy[Services, State]); ok {
+				if expected := []string{"Step1", "Step2", "Step3"}; !reflect.DeepEqual(order, expected) {
+					t.Errorf("expected result order %v, got %v", expected, order)
+				}
+			}
+		})
+	}
+}
+
+// FuzzMachine_DecisionLog runs step graphs whose control flow is drawn from the fuzz input and
+// checks that replaying the decision log reproduces it, whatever the steps return on replay.
+func FuzzMachine_DecisionLog(f *testing.F) {
+	f.Add([]byte{0, 0, 0})
+	f.Add([]byte{1, 0, 2, 5, 0, 3})
+	f.Add([]byte{2, 7, 1, 2, 0, 0, 3, 1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 || len(data) > 16 {
+			t.Skip()
+		}
+
+		names := make([]string, len(data))
+		for i := range data {
+			names[i] = fmt.Sprintf("Step%d", i)
+		}
+
+		var recorded []tango.Step[Services, State]
+		var replayed []tango.Step[Services, State]
+		for i, b := range data {
+			recorded = append(recorded, tango.Step[Services, State]{
+				Name: names[i],
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					remaining := len(names) - i - 1
+					switch b % 4 {
+					case 1:
+						return ctx.Machine.Skip(i, int(b/4)%(remaining+1)), nil
+					case 2:
+						if remaining > 0 {
+							return ctx.Machine.Jump(i, names[i+1+int(b/4)%remaining]), nil
+						}
+					case 3:
+						return ctx.Machine.Done(i), nil
+					}
+					return ctx.Machine.Next(i), nil
+				},
+			})
+			replayed = append(replayed, tango.Step[Services, State]{
+				Name: names[i],
+				Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+					return ctx.Machine.Next(i), nil
+				},
+			})
+		}
+
+		m := tango.NewMachine("FuzzMachine", recorded, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+		if _, err := m.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		log := m.DecisionLog()
+		if len(log) == 0 {
+			t.Fatalf("expected at least one decision")
+		}
+
+		replay := tango.NewMachine("FuzzMachine", replayed, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+		if _, err := replay.Replay(tango.Trace{Machine: "FuzzMachine", Decisions: log}); err != nil {
+			t.Fatalf("unexpected replay error: %v", err)
+		}
+		if replayedLog := replay.DecisionLog(); !reflect.DeepEqual(replayedLog, log) {
+			t.Errorf("expected replayed decisions %+v, got %+v", log, replayedLog)
+		}
+	})
+}
+
+type exportScriptTestCase struct {
+	name     string
+	steps    []tango.Step[Services, State]
+	expected string
+}
+
+func TestMachine_ExportScript(t *testing.T) {
+	step := func(name string, respond func(m *tango.Machine[Services, State]) *tango.Response[Services, State]) tango.Step[Services, State] {
+		return tango.Step[Services, State]{
+			Name: name,
+			Execute: func(ctx *tango.MachineContext[Services, State]) (*tango.Response[Services, State], error) {
+				return respond(ctx.Machine), nil
+			},
+		}
+	}
+	next := func(m *tango.Machine[Services, State]) *tango.Response[Services, State] { return m.Next(nil) }
+
+	tests := []exportScriptTestCase{
+		{
+			name: "JumpAndSkip",
+			steps: []tango.Step[Services, State]{
+				step("Reserve", next),
+				step("Check", func(m *tango.Machine[Services, State]) *tango.Response[Services, State] { return m.Jump(nil, "Gate") }),
+				step("Backorder", next),
+				step("Gate", func(m *tango.Machine[Services, State]) *tango.Response[Services, State] { return m.Skip(nil, 1) }),
+				step("Expedite", next),
+				step("Notify", func(m *tango.Machine[Services, State]) *tango.Response[Services, State] { return m.Done(nil) }),
+			},
+			expected: "Reserve -> NEXT\nCheck -> JUMP -> Gate\nGate -> SKIP 1\nNotify -> DONE\n",
+		},
+		{
+			name: "Sequential",
+			steps: []tango.Step[Services, State]{
+				step("Reserve", next),
+				step("Charge", next),
+			},
+			expected: "Reserve -> NEXT\nCharge -> NEXT\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tango.NewMachine("TestMachine", tt.steps, &tango.MachineContext[Services, State]{}, &tango.MachineConfig[Services, State]{}, &tango.SequentialStrategy[Services, State]{})
+			if _, err := m.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if script := m.ExportScript(); script != tt.expected {
+				t.Errorf("expected script:\n%s\ngot:\n%s", tt.expected, script)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package tango
+
+import "time"
+
+// View is a read-only snapshot of a run's progress, for monitoring a machine while it runs. A
+// View is never modified once published, and must not be modified by its readers.
+type View[Services, State any] struct {
+	RunID string
+	// Decisions holds the decisions recorded in the run so far, in order.
+	Decisions      []Decision
+	PreviousResult *Response[Services, State]
+	// State is the machine's state as of the latest step, copied by value: pointers, maps and
+	// slices in it are shared with the running machine.
+	State     State
+	UpdatedAt time.Time
+}
+
+// View returns the latest snapshot of the machine's progress without taking the machine's
+// lock, so it can be polled often without slowing a run down. The snapshot is published when
+// a run starts and after each step. It is nil before the first run.
+//
+// Under ConcurrentStrategy, the snapshot's State is read while other steps may be running, so
+// steps should fold their changes into the state with MachineConfig.Reduce rather than write
+// ctx.State directly.
+func (m *Machine[Services, State]) View() *View[Services, State] {
+	return m.view.Load()
+}
+
+// publishView publishes a snapshot of the run's progress for View. m.mu must be held.
+func (m *Machine[Services, State]) publishView() {
+	m.view.Store(&View[Services, State]{
+		RunID:          m.Context.RunID,
+		Decisions:      m.decisions[:len(m.decisions):len(m.decisions)],
+		PreviousResult: m.Context.PreviousResult,
+		State:          m.Context.State,
+		UpdatedAt:      time.Now(),
+	})
+}